@@ -2,24 +2,28 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/wellplayedgames/unity-installer/pkg/installer"
 	"github.com/wellplayedgames/unity-installer/pkg/release"
+	"io/ioutil"
 	"os"
+	"strings"
 )
 
 type apply struct {
-	Spec       string   `required arg help:"Spec file to apply"`
+	Spec       string   `arg:"" optional:"" help:"Spec file to apply"`
+	Bundle     string   `help:"Offline bundle directory or .tar file to install from" type:"path"`
 	Modules    []string `name:"module" help:"Extra modules to install whilst applying"`
 	Force      bool     `help:"Reinstall Unity"`
 	SkipEditor bool     `help:"If true, don't install the editor'"`
 }
 
-func (a *apply) Run(ctx commandContext) error {
+func loadSpec(ctx commandContext, path string) (*release.EditorRelease, error) {
 	spec := &release.EditorRelease{}
-	f, err := os.Open(a.Spec)
+	f, err := os.Open(path)
 	if err != nil {
-		return fmt.Errorf("failed to open spec: %w", err)
+		return nil, fmt.Errorf("failed to open spec: %w", err)
 	}
 	defer func() {
 		if err := f.Close(); err != nil {
@@ -30,7 +34,55 @@ func (a *apply) Run(ctx commandContext) error {
 	d := json.NewDecoder(f)
 	err = d.Decode(spec)
 	if err != nil {
-		return fmt.Errorf("failed to decode spec: %w", err)
+		return nil, fmt.Errorf("failed to decode spec: %w", err)
+	}
+
+	return spec, nil
+}
+
+func openBundle(ctx commandContext, bundlePath string) (*release.EditorRelease, error) {
+	info, err := os.Stat(bundlePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bundle: %w", err)
+	}
+
+	bundleDir := bundlePath
+
+	if !info.IsDir() {
+		if !info.Mode().IsRegular() || !strings.HasSuffix(bundlePath, ".tar") {
+			return nil, fmt.Errorf("bundle %s must be a directory or .tar file", bundlePath)
+		}
+
+		dir, err := ioutil.TempDir(ctx.tempDir, "bundle")
+		if err != nil {
+			return nil, err
+		}
+
+		ctx.logger.Info("extracting bundle", "bundle", bundlePath)
+		if err := installer.ExtractBundleTar(bundlePath, dir); err != nil {
+			return nil, fmt.Errorf("failed to extract bundle: %w", err)
+		}
+		bundleDir = dir
+	}
+
+	return installer.OpenBundle(bundleDir)
+}
+
+func (a *apply) Run(ctx commandContext) error {
+	var spec *release.EditorRelease
+	var err error
+
+	if a.Bundle != "" && a.Spec != "" {
+		err = errors.New("a spec cannot be applied with --bundle")
+	} else if a.Bundle != "" {
+		spec, err = openBundle(ctx, a.Bundle)
+	} else if a.Spec != "" {
+		spec, err = loadSpec(ctx, a.Spec)
+	} else {
+		err = errors.New("either a spec or --bundle is required")
+	}
+	if err != nil {
+		return err
 	}
 
 	var installModules []string
@@ -51,12 +103,17 @@ func (a *apply) Run(ctx commandContext) error {
 		}
 	}
 
-	pkgInstaller := newPackageInstaller(ctx.logger)
+	// Missing modules are reported when installing.
+	if modules, err := installer.ResolveModules(spec, installModules); err == nil {
+		logEULAs(ctx, modules)
+	}
+
+	pkgInstaller := newPackageInstaller(ctx.logger, ctx.observer)
 	defer func() {
 		if err := pkgInstaller.Close(); err != nil {
 			ctx.logger.Error(err, "failed to shutdown package installer")
 		}
 	}()
 
-	return installer.EnsureEditorWithModules(CLI.Platform, ctx.installer, pkgInstaller, spec, installModules, a.Force, a.SkipEditor)
+	return installer.EnsureEditorWithModules(ctx.ctx, CLI.Platform, ctx.installer, pkgInstaller, spec, installModules, a.Force, a.SkipEditor, CLI.ParallelDownloads)
 }
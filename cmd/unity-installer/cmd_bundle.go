@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"github.com/wellplayedgames/unity-installer/pkg/installer"
+	"github.com/wellplayedgames/unity-installer/pkg/release"
+	"io/ioutil"
+	"strings"
+)
+
+type bundle struct {
+	versionSelector
+	Spec   string `help:"Spec file to bundle instead of looking up a version" type:"existingfile"`
+	Output string `short:"o" required:"" help:"Output directory for the bundle, or a path ending in .tar to create a single file"`
+}
+
+func (b *bundle) Run(ctx commandContext) error {
+	var spec *release.EditorRelease
+	var err error
+
+	if b.Spec != "" {
+		spec, err = loadSpec(ctx, b.Spec)
+		if err != nil {
+			return err
+		}
+
+		modules, err := installer.ResolveModules(spec, b.Modules)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range modules {
+			m.Selected = true
+		}
+	} else {
+		spec, err = distillSpec(ctx, &b.versionSelector)
+		if err != nil {
+			return err
+		}
+	}
+
+	dir := b.Output
+	isTar := strings.HasSuffix(b.Output, ".tar")
+	if isTar {
+		dir, err = ioutil.TempDir(ctx.tempDir, "bundle")
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx.logger.Info("creating bundle", "version", spec.Version, "output", b.Output)
+	if err := installer.CreateBundle(ctx.ctx, ctx.downloader, spec, dir, CLI.ParallelDownloads); err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+
+	if isTar {
+		if err := installer.WriteBundleTar(dir, b.Output); err != nil {
+			return fmt.Errorf("failed to write bundle: %w", err)
+		}
+	}
+
+	return nil
+}
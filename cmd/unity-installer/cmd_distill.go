@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/wellplayedgames/unity-installer/pkg/installer"
 	"github.com/wellplayedgames/unity-installer/pkg/release"
 	"io"
 	"os"
@@ -13,21 +14,31 @@ type distill struct {
 	Output string `short:"o" help:"Output path for spec (defaults to stdout)"`
 }
 
-func (d *distill) Run(ctx commandContext) error {
-	version, revision, err := d.VersionAndRevision()
+// distillSpec looks up the release chosen by a version selector and marks its
+// requested modules as selected.
+func distillSpec(ctx commandContext, s *versionSelector) (*release.EditorRelease, error) {
+	version, revision, err := s.VersionAndRevision()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	editorRelease, err := ctx.LookupTargetRelease(version, revision)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	spec := &*editorRelease
+
+	// Select dependencies too so that bundles include them.
+	resolved, err := installer.ResolveModules(spec, s.Modules)
+	if err != nil {
+		return nil, err
+	}
+	logEULAs(ctx, resolved)
+
 	selectedModules := map[string]bool{}
-	for _, moduleID := range d.Modules {
-		selectedModules[moduleID] = true
+	for _, m := range resolved {
+		selectedModules[m.ID] = true
 	}
 
 	modules := make([]release.ModuleRelease, len(spec.Modules))
@@ -39,6 +50,14 @@ func (d *distill) Run(ctx commandContext) error {
 	}
 
 	spec.Modules = modules
+	return spec, nil
+}
+
+func (d *distill) Run(ctx commandContext) error {
+	spec, err := distillSpec(ctx, &d.versionSelector)
+	if err != nil {
+		return err
+	}
 
 	var output io.Writer = os.Stdout
 
@@ -1,18 +1,35 @@
 package main
 
 import (
+	"errors"
+
 	"github.com/wellplayedgames/unity-installer/pkg/editor"
 	"github.com/wellplayedgames/unity-installer/pkg/installer"
+	"github.com/wellplayedgames/unity-installer/pkg/release"
 )
 
 type versionSelector struct {
+	Link       string   `arg:"" optional:"" help:"Unity Hub link to install (unityhub://<version>/<revision>)"`
+	HubLink    string   `help:"Unity Hub link to install (unityhub://<version>/<revision>)"`
 	ForProject string   `help:"Path to Unity project to match version for"`
-	Version    string   `help:"Unity version to install"`
-	Revision   string   `help:"Unity revision to install"`
+	Version    string   `help:"Unity version to install: an exact version, a wildcard (2020.3.x), a range (>=2021.3.10f1 <2022) or latest, latest-lts, latest-beta, latest-alpha, optionally followed by :<version prefix>"`
+	Revision   string   `help:"Unity revision to install (only with an exact version)"`
 	Modules    []string `name:"module" help:"Extra modules to install (can be repeated to specify multiple modules)"`
 }
 
 func (s *versionSelector) VersionAndRevision() (string, string, error) {
+	if s.Link != "" && s.HubLink != "" {
+		return "", "", errors.New("a Unity Hub link cannot be given both as an argument and with --hub-link")
+	}
+
+	if link := s.Link + s.HubLink; link != "" {
+		if s.ForProject != "" || s.Version != "" || s.Revision != "" {
+			return "", "", errors.New("a Unity Hub link cannot be combined with --for-project, --version or --revision")
+		}
+
+		return editor.ParseHubLink(link)
+	}
+
 	if s.ForProject != "" {
 		pv, err := editor.ProjectVersionFromProject(s.ForProject)
 		if err != nil {
@@ -26,6 +43,16 @@ func (s *versionSelector) VersionAndRevision() (string, string, error) {
 	return s.Version, s.Revision, nil
 }
 
+// logEULAs logs the licence agreements of modules which are being
+// installed, which Unity Hub would ask to be accepted.
+func logEULAs(ctx commandContext, modules []*release.ModuleRelease) {
+	for _, m := range modules {
+		for _, eula := range m.EULAs {
+			ctx.logger.Info("module is subject to a licence agreement", "module", m.ID, "licence", eula.Label, "url", eula.URL)
+		}
+	}
+}
+
 type install struct {
 	versionSelector
 	Force      bool `help:"Reinstall Unity"`
@@ -33,28 +60,40 @@ type install struct {
 }
 
 func (i *install) Run(ctx commandContext) error {
+	version, revision, err := i.VersionAndRevision()
+	if err != nil {
+		return err
+	}
+
+	// Exact versions can be checked without looking up the release.
 	if !i.Force {
-		if has, _ := installer.HasEditorAndModules(ctx.installer, i.Version, i.Modules); has {
+		if has, _ := installer.HasEditorAndModules(ctx.installer, version, i.Modules); has {
 			return nil
 		}
 	}
 
-	pkgInstaller := newPackageInstaller(ctx.logger)
-	defer func() {
-		if err := pkgInstaller.Close(); err != nil {
-			ctx.logger.Error(err, "failed to shutdown package installer")
-		}
-	}()
-
-	version, revision, err := i.VersionAndRevision()
+	editorRelease, err := ctx.LookupTargetRelease(version, revision)
 	if err != nil {
 		return err
 	}
 
-	editorRelease, err := ctx.LookupTargetRelease(version, revision)
-	if err != nil {
-		return err
+	if !i.Force && editorRelease.Version != version {
+		if has, _ := installer.HasEditorAndModules(ctx.installer, editorRelease.Version, i.Modules); has {
+			return nil
+		}
+	}
+
+	// Missing modules are reported when installing.
+	if modules, err := installer.ResolveModules(editorRelease, i.Modules); err == nil {
+		logEULAs(ctx, modules)
 	}
 
-	return installer.EnsureEditorWithModules(CLI.Platform, ctx.installer, pkgInstaller, editorRelease, i.Modules, i.Force, i.SkipEditor)
+	pkgInstaller := newPackageInstaller(ctx.logger, ctx.observer)
+	defer func() {
+		if err := pkgInstaller.Close(); err != nil {
+			ctx.logger.Error(err, "failed to shutdown package installer")
+		}
+	}()
+
+	return installer.EnsureEditorWithModules(ctx.ctx, CLI.Platform, ctx.installer, pkgInstaller, editorRelease, i.Modules, i.Force, i.SkipEditor, CLI.ParallelDownloads)
 }
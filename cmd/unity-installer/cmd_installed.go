@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/wellplayedgames/unity-installer/pkg/editor"
+	"github.com/wellplayedgames/unity-installer/pkg/installer"
+)
+
+type installed struct {
+	SearchPath []string `help:"Extra directories to look for editors in, besides the install path (can be repeated)" env:"UNITY_SEARCH_PATHS"`
+	Format     string   `help:"Output format" enum:"table,json" default:"table"`
+}
+
+// lessVersion orders versions oldest first, with anything which isn't a
+// Unity version last.
+func lessVersion(a, b string) bool {
+	validA, validB := editor.IsValidVersion(a), editor.IsValidVersion(b)
+	if validA && validB {
+		return editor.CompareVersions(a, b) < 0
+	} else if validA != validB {
+		return validA
+	}
+
+	return a < b
+}
+
+func (i *installed) Run(ctx commandContext) error {
+	var editors []installer.InstalledEditor
+	seen := map[string]bool{}
+
+	for _, dir := range append([]string{CLI.InstallPath}, i.SearchPath...) {
+		found, err := installer.FindInstalledEditors(ctx.logger.WithName("installed"), dir)
+		if err != nil {
+			return fmt.Errorf("failed to scan %s: %w", dir, err)
+		}
+
+		for _, e := range found {
+			if !seen[e.Path] {
+				seen[e.Path] = true
+				editors = append(editors, e)
+			}
+		}
+	}
+
+	sort.SliceStable(editors, func(a, b int) bool {
+		return lessVersion(editors[a].Version, editors[b].Version)
+	})
+
+	if i.Format == "json" {
+		if editors == nil {
+			editors = []installer.InstalledEditor{}
+		}
+
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "  ")
+		return e.Encode(editors)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tREVISION\tPLATFORM\tARCH\tSIZE (MB)\tMODULES\tPATH")
+	for _, e := range editors {
+		modules := strings.Join(e.Modules, ",")
+		if !e.HasModulesFile {
+			modules = "(no modules.json)"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			e.Version, e.Revision, e.Platform, e.Architecture, e.Size/(1024*1024), modules, e.Path)
+	}
+	return w.Flush()
+}
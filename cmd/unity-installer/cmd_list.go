@@ -1,28 +1,156 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/wellplayedgames/unity-installer/pkg/editor"
+	"github.com/wellplayedgames/unity-installer/pkg/release"
 )
 
-type list struct{}
+type list struct {
+	LTS      bool   `help:"Include LTS releases"`
+	Official bool   `help:"Include official (non-prerelease) releases"`
+	Beta     bool   `help:"Include beta releases"`
+	Alpha    bool   `help:"Include alpha releases"`
+	Since    string `help:"Only list this version and newer"`
+	Range    string `help:"Only list versions matched by a version selector, such as '>=2021.3 <2022'"`
+	Format   string `help:"Output format" enum:"plain,table,json" default:"plain"`
+}
+
+type listEntry struct {
+	Version      string   `json:"version"`
+	Revision     string   `json:"revision,omitempty"`
+	Stream       string   `json:"stream"`
+	LTS          bool     `json:"lts"`
+	DownloadSize int64    `json:"downloadSize"`
+	Modules      []string `json:"modules"`
+}
+
+// matchesStreams returns true if a release is in one of the requested
+// streams, or if no streams were requested.
+func (l *list) matchesStreams(r *release.EditorRelease, version string) bool {
+	if !l.LTS && !l.Official && !l.Beta && !l.Alpha {
+		return true
+	}
+
+	switch editor.VersionStream(version) {
+	case editor.StreamAlpha:
+		return l.Alpha
+	case editor.StreamBeta:
+		return l.Beta
+	default:
+		return l.Official || (l.LTS && r.LTS)
+	}
+}
+
+// entries returns the releases to list, oldest first.
+func (l *list) entries(releases release.Releases) ([]listEntry, error) {
+	var selector, since *editor.VersionSelector
+
+	if l.Since != "" {
+		if !editor.IsValidVersion(l.Since) {
+			return nil, fmt.Errorf("invalid version %q", l.Since)
+		}
+
+		var err error
+		since, err = editor.ParseVersionSelector(">=" + l.Since)
+		if err != nil {
+			return nil, err
+		}
+		since.Stream = ""
+	}
+
+	if l.Range != "" {
+		var err error
+		selector, err = editor.ParseVersionSelector(l.Range)
+		if err != nil {
+			return nil, err
+		}
+
+		// Ranges only match official releases unless a bound is a
+		// pre-release, so leave streams to the stream filters.
+		if !strings.HasPrefix(l.Range, "latest") {
+			selector.Stream = ""
+		}
+	}
+
+	var entries []listEntry
+
+	for version, r := range releases {
+		if !editor.IsValidVersion(version) || !l.matchesStreams(r, version) {
+			continue
+		}
+
+		if selector != nil && !selector.Match(version, r.LTS) {
+			continue
+		}
+
+		if since != nil && !since.Match(version, r.LTS) {
+			continue
+		}
+
+		modules := make([]string, 0, len(r.Modules))
+		for idx := range r.Modules {
+			modules = append(modules, r.Modules[idx].ID)
+		}
+		sort.Strings(modules)
+
+		entries = append(entries, listEntry{
+			Version:      version,
+			Revision:     r.Revision,
+			Stream:       editor.VersionStream(version),
+			LTS:          r.LTS,
+			DownloadSize: int64(r.DownloadSize),
+			Modules:      modules,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return editor.CompareVersions(entries[i].Version, entries[j].Version) < 0
+	})
+
+	return entries, nil
+}
 
 func (l *list) Run(ctx commandContext) error {
-	latestReleases, err := ctx.releaseSource.FetchReleases(CLI.Platform, true)
+	includeBeta := l.Beta || l.Alpha || (!l.LTS && !l.Official)
+	latestReleases, err := ctx.releaseSource.FetchReleases(ctx.ctx, CLI.Platform, includeBeta)
 	if err != nil {
 		return err
 	}
 
-	versions := make([]string, 0, len(latestReleases))
-	for version := range latestReleases {
-		versions = append(versions, version)
+	entries, err := l.entries(latestReleases)
+	if err != nil {
+		return err
 	}
 
-	sort.Strings(versions)
+	switch l.Format {
+	case "json":
+		if entries == nil {
+			entries = []listEntry{}
+		}
 
-	for _, version := range versions {
-		fmt.Println(version)
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "  ")
+		return e.Encode(entries)
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tREVISION\tSTREAM\tLTS\tSIZE (MB)\tMODULES")
+		for _, entry := range entries {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%d\t%s\n",
+				entry.Version, entry.Revision, entry.Stream, entry.LTS,
+				entry.DownloadSize/(1024*1024), strings.Join(entry.Modules, ","))
+		}
+		return w.Flush()
+	default:
+		for _, entry := range entries {
+			fmt.Println(entry.Version)
+		}
+		return nil
 	}
-
-	return nil
 }
@@ -0,0 +1,44 @@
+package main
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/wellplayedgames/unity-installer/pkg/release"
+)
+
+var _ = Describe("list", func() {
+	releases := release.Releases{
+		"2019.4.10p1": {},
+		"2019.4.9f1":  {},
+		"2019.4.9f10": {},
+		"2019.4.9f2":  {},
+		"2020.1.0b14": {},
+		"2020.1.0b2":  {},
+		"2020.1.0a25": {},
+	}
+
+	versions := func(l *list) []string {
+		entries, err := l.entries(releases)
+		Expect(err).NotTo(HaveOccurred())
+
+		var versions []string
+		for _, entry := range entries {
+			versions = append(versions, entry.Version)
+		}
+		return versions
+	}
+
+	It("should list versions oldest first", func() {
+		Expect(versions(&list{})).To(Equal([]string{
+			"2019.4.9f1", "2019.4.9f2", "2019.4.9f10", "2019.4.10p1",
+			"2020.1.0a25", "2020.1.0b2", "2020.1.0b14",
+		}))
+	})
+
+	It("should list versions since a version", func() {
+		Expect(versions(&list{Since: "2019.4.9f2"})).To(Equal([]string{
+			"2019.4.9f2", "2019.4.9f10", "2019.4.10p1",
+			"2020.1.0a25", "2020.1.0b2", "2020.1.0b14",
+		}))
+	})
+})
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/wellplayedgames/unity-installer/pkg/editor"
+	"github.com/wellplayedgames/unity-installer/pkg/installer"
+	"github.com/wellplayedgames/unity-installer/pkg/progress"
+)
+
+type prune struct {
+	KeepNewest int      `help:"Keep this many of the newest versions of each major version, such as 2021.3"`
+	Project    []string `help:"Keep the version used by this Unity project (can be repeated)"`
+	Pin        []string `help:"Keep versions matched by this version selector, such as 2021.3.x (can be repeated)"`
+	UnusedDays int      `help:"Only remove editors which have not been used for this many days"`
+}
+
+func (p *prune) policy() (*installer.PrunePolicy, error) {
+	if p.KeepNewest <= 0 && len(p.Project) == 0 && len(p.Pin) == 0 && p.UnusedDays <= 0 {
+		return nil, errors.New("no prune policy given: use --keep-newest, --project, --pin or --unused-days")
+	}
+
+	policy := &installer.PrunePolicy{
+		KeepNewest: p.KeepNewest,
+		UnusedFor:  time.Duration(p.UnusedDays) * 24 * time.Hour,
+	}
+
+	for _, project := range p.Project {
+		pv, err := editor.ProjectVersionFromProject(project)
+		if err != nil {
+			return nil, err
+		}
+
+		version, _ := pv.VersionAndRevision()
+		policy.KeepVersions = append(policy.KeepVersions, version)
+	}
+
+	for _, pin := range p.Pin {
+		selector, err := editor.ParseVersionSelector(pin)
+		if err != nil {
+			return nil, err
+		}
+		policy.Pinned = append(policy.Pinned, selector)
+	}
+
+	return policy, nil
+}
+
+func (p *prune) Run(ctx commandContext) error {
+	policy, err := p.policy()
+	if err != nil {
+		return err
+	}
+
+	editors, err := installer.FindInstalledEditors(ctx.logger.WithName("prune"), CLI.InstallPath)
+	if err != nil {
+		return fmt.Errorf("failed to scan %s: %w", CLI.InstallPath, err)
+	}
+
+	decisions := policy.Plan(editors, time.Now())
+
+	var freed int64
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ACTION\tVERSION\tLAST USED\tSIZE\tREASON")
+	for _, d := range decisions {
+		action := "keep"
+		if d.Remove {
+			action = "remove"
+			freed += d.Size
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			action, d.Version, d.LastUsed.Format("2006-01-02"), progress.FormatBytes(d.Size), d.Reason)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if CLI.DryRun {
+		ctx.logger.Info("dry run, not removing editors", "wouldFree", progress.FormatBytes(freed))
+		return nil
+	}
+
+	pkgInstaller := newPackageInstaller(ctx.logger, ctx.observer)
+	defer func() {
+		if err := pkgInstaller.Close(); err != nil {
+			ctx.logger.Error(err, "failed to shutdown package installer")
+		}
+	}()
+
+	if err := installer.PruneEditors(pkgInstaller, CLI.InstallPath, decisions); err != nil {
+		return err
+	}
+
+	ctx.logger.Info("pruned editors", "freed", progress.FormatBytes(freed))
+	return nil
+}
@@ -0,0 +1,32 @@
+package main
+
+type uninstall struct {
+	Version string   `help:"Unity version to uninstall" required:""`
+	Modules []string `name:"module" help:"Modules to uninstall instead of the whole editor (can be repeated). Modules which depend on them are uninstalled too."`
+}
+
+func (u *uninstall) Run(ctx commandContext) error {
+	pkgInstaller := newPackageInstaller(ctx.logger, ctx.observer)
+	defer func() {
+		if err := pkgInstaller.Close(); err != nil {
+			ctx.logger.Error(err, "failed to shutdown package installer")
+		}
+	}()
+
+	if len(u.Modules) == 0 {
+		if err := ctx.installer.UninstallEditor(pkgInstaller, u.Version); err != nil {
+			return err
+		}
+
+		ctx.logger.Info("uninstalled editor", "version", u.Version, "dryRun", CLI.DryRun)
+		return nil
+	}
+
+	removed, err := ctx.installer.UninstallModules(pkgInstaller, u.Version, u.Modules)
+	if err != nil {
+		return err
+	}
+
+	ctx.logger.Info("uninstalled modules", "version", u.Version, "modules", removed, "dryRun", CLI.DryRun)
+	return nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/wellplayedgames/unity-installer/pkg/installer"
+	packageinstaller "github.com/wellplayedgames/unity-installer/pkg/package-installer"
+	"github.com/wellplayedgames/unity-installer/pkg/release"
+)
+
+type verify struct {
+	Version string `help:"Unity version to verify" required:""`
+	Repair  bool   `help:"Reinstall the editor and modules with missing or modified files"`
+	Format  string `help:"Output format" enum:"plain,json" default:"plain"`
+}
+
+func (v *verify) printReport(report *installer.InstallReport) error {
+	if v.Format == "json" {
+		if report.Problems == nil {
+			report.Problems = []installer.FileProblem{}
+		}
+
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "  ")
+		return e.Encode(report)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, p := range report.Problems {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", p.Problem, p.Package, p.Path)
+	}
+	return w.Flush()
+}
+
+// repair reinstalls the packages with missing or modified files.
+func (v *verify) repair(ctx commandContext, damaged []string) error {
+	_, installed, err := ctx.installer.CheckEditorVersion(v.Version)
+	if err != nil {
+		return err
+	}
+
+	editorRelease := &release.EditorRelease{Version: v.Version}
+	repairEditor := false
+	var moduleIDs []string
+
+	for _, name := range damaged {
+		if name == packageinstaller.EditorManifest {
+			repairEditor = true
+		} else {
+			moduleIDs = append(moduleIDs, name)
+		}
+	}
+
+	if repairEditor {
+		// Reinstall the same build which was verified.
+		manifest, err := packageinstaller.ReadManifest(ctx.installer.EditorPath(v.Version), packageinstaller.EditorManifest)
+		if err != nil {
+			return fmt.Errorf("failed to read editor manifest: %w", err)
+		}
+
+		found, err := ctx.LookupTargetRelease(v.Version, manifest.Revision)
+		if err != nil {
+			return err
+		}
+
+		r := *found
+		editorRelease = &r
+	}
+
+	// Reinstall modules from the specs they were installed with.
+	editorRelease.Modules = installed
+
+	pkgInstaller := newPackageInstaller(ctx.logger, ctx.observer)
+	defer func() {
+		if err := pkgInstaller.Close(); err != nil {
+			ctx.logger.Error(err, "failed to shutdown package installer")
+		}
+	}()
+
+	ctx.logger.Info("repairing", "version", v.Version, "editor", repairEditor, "modules", moduleIDs)
+	if err := installer.EnsureEditorWithModules(ctx.ctx, CLI.Platform, ctx.installer, pkgInstaller, editorRelease, moduleIDs, true, !repairEditor, CLI.ParallelDownloads); err != nil {
+		return err
+	}
+
+	if !repairEditor {
+		return nil
+	}
+
+	// Reinstalling the editor resets the module state, but the other
+	// modules' files were left in place.
+	_, repaired, err := ctx.installer.CheckEditorVersion(v.Version)
+	if err != nil {
+		return err
+	}
+
+	selected := map[string]bool{}
+	for _, m := range installed {
+		selected[m.ID] = m.Selected
+	}
+
+	for idx := range repaired {
+		repaired[idx].Selected = repaired[idx].Selected || selected[repaired[idx].ID]
+	}
+
+	return pkgInstaller.StoreModules(ctx.installer.EditorPath(v.Version), repaired)
+}
+
+func (v *verify) Run(ctx commandContext) error {
+	report, err := installer.CheckEditorInstall(ctx.ctx, ctx.installer, v.Version)
+	if err != nil {
+		return err
+	}
+
+	if len(report.Unverified) > 0 {
+		ctx.logger.Info("some packages have no manifest and were not checked; extra files were not looked for", "packages", report.Unverified)
+	}
+
+	if v.Repair {
+		if damaged := report.DamagedPackages(); len(damaged) > 0 {
+			if err := v.repair(ctx, damaged); err != nil {
+				return err
+			}
+
+			if report, err = installer.CheckEditorInstall(ctx.ctx, ctx.installer, v.Version); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := v.printReport(report); err != nil {
+		return err
+	}
+
+	if len(report.Problems) > 0 {
+		return fmt.Errorf("Unity %s has %d missing, modified or extra files", v.Version, len(report.Problems))
+	}
+
+	ctx.logger.Info("verified editor", "version", v.Version)
+	return nil
+}
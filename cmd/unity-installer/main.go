@@ -2,42 +2,81 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"io/ioutil"
 	"log"
+	"math"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/alecthomas/kong"
 	"github.com/go-logr/logr"
 	"github.com/go-logr/stdr"
+	"github.com/wellplayedgames/unity-installer/pkg/editor"
 	"github.com/wellplayedgames/unity-installer/pkg/installer"
 	pkginstaller "github.com/wellplayedgames/unity-installer/pkg/package-installer"
+	"github.com/wellplayedgames/unity-installer/pkg/progress"
 	"github.com/wellplayedgames/unity-installer/pkg/release"
+	"github.com/wellplayedgames/unity-installer/pkg/retry"
 )
 
 type commandContext struct {
 	ctx           context.Context
 	logger        logr.Logger
+	observer      progress.Observer
 	releaseSource release.Source
 	installer     installer.UnityInstaller
+	downloader    *installer.Downloader
+	tempDir       string
 }
 
 var CLI struct {
-	ReleasesEndpoint string `help:"Endpoint to fetch Unity releases from" env:"UNITY_RELEASES_ENDPOINT"`
-	ArchiveEndpoint  string `help:"Endpoint to fetch archived Unity releases from" env:"UNITY_ARCHIVE_ENDPOINT"`
+	ReleasesEndpoint string   `help:"Endpoint to fetch Unity releases from" env:"UNITY_RELEASES_ENDPOINT"`
+	ArchiveEndpoint  string   `help:"Endpoint to fetch archived Unity releases from" env:"UNITY_ARCHIVE_ENDPOINT"`
+	ReleaseAPI       string   `help:"Endpoint of the Unity Release API, used by the unity-api release source" env:"UNITY_RELEASE_API_ENDPOINT"`
+	ReleaseCatalog   string   `help:"Directory or file:// URL of a release catalog to use instead of the Unity endpoints" env:"UNITY_RELEASE_CATALOG"`
+	ReleaseSource    []string `help:"Release sources to try in order: unity, unity-api, a mirror URL or a release catalog (can be repeated)" env:"UNITY_RELEASE_SOURCES"`
+	RevisionIndex    string   `help:"URL or path of a version to revision index (JSON, or a page of unityhub:// links) used to find archived versions, empty to disable" env:"UNITY_REVISION_INDEX" default:"${default_revision_index}"`
 
 	InstallPath string `help:"Directory to install Unity editors into" env:"UNITY_INSTALL_PATH" default:"C:\\Program Files\\Unity"`
 	Platform    string `help:"Unity host platform" env:"UNITY_PLATFORM" default:"${default_platform}"`
+	Arch        string `help:"Editor architecture: x86_64 or arm64" env:"UNITY_ARCH" default:"${default_arch}"`
 
 	DryRun bool `help:"Don't actually install anything when requested, just print what would have been run." env:"DRY_RUN"`
 
-	Install install `cmd help:"Install a Unity version (optionally with modules)"`
-	Distill distill `cmd help:"Create an install spec to install later"`
-	Apply   apply   `cmd help:"Apply a previously distilled install spec"`
-	List    list    `cmd help:"List available Unity versions"`
+	ReleaseCacheDir string        `help:"Directory to cache release metadata in (defaults to the user cache directory)" env:"UNITY_RELEASE_CACHE_DIR"`
+	ReleaseCacheTTL time.Duration `help:"How long cached release metadata is used before being revalidated" env:"UNITY_RELEASE_CACHE_TTL" default:"1h"`
+	Offline         bool          `help:"Only use cached release metadata, even if it is stale" env:"UNITY_OFFLINE"`
+
+	CacheDir     string `help:"Directory to cache downloaded packages in between runs, which also lets interrupted downloads resume in a later run" env:"UNITY_CACHE_DIR"`
+	CacheMaxSize int64  `help:"Maximum size of the download cache in megabytes (0 for unlimited)" env:"UNITY_CACHE_MAX_SIZE" default:"0"`
+
+	ParallelDownloads int `help:"Number of packages to download at the same time" env:"UNITY_PARALLEL_DOWNLOADS" default:"4"`
+
+	RetryAttempts int           `help:"Number of attempts to make for each HTTP request" env:"UNITY_RETRY_ATTEMPTS" default:"4"`
+	RetryDelay    time.Duration `help:"Initial delay between HTTP retries, doubled after each attempt" env:"UNITY_RETRY_DELAY" default:"1s"`
+	RetryMaxDelay time.Duration `help:"Maximum delay between HTTP retries" env:"UNITY_RETRY_MAX_DELAY" default:"30s"`
+
+	Rewrite         []string `help:"Rewrite download URLs as from=to (or from => to if from contains =), where from is a URL prefix or regex:<pattern> (can be repeated)" env:"UNITY_URL_REWRITE" sep:"none"`
+	RewriteFile     string   `help:"File of download URL rewrite rules, one per line" env:"UNITY_URL_REWRITE_FILE" type:"existingfile"`
+	RewriteFallback bool     `help:"Fall back to the original URL if a rewritten download fails" env:"UNITY_URL_REWRITE_FALLBACK"`
+
+	Install   install   `cmd:"" help:"Install a Unity version (optionally with modules)"`
+	Distill   distill   `cmd:"" help:"Create an install spec to install later"`
+	Apply     apply     `cmd:"" help:"Apply a previously distilled install spec"`
+	List      list      `cmd:"" help:"List available Unity versions"`
+	Bundle    bundle    `cmd:"" help:"Download an install spec's packages for offline installs"`
+	Uninstall uninstall `cmd:"" help:"Uninstall a Unity version or some of its modules"`
+	Verify    verify    `cmd:"" help:"Check an installed Unity version's files against their manifests"`
+	Installed installed `cmd:"" help:"List installed Unity versions"`
+	Prune     prune     `cmd:"" help:"Remove old installed Unity versions"`
 }
 
 func getPlatform() string {
@@ -49,8 +88,136 @@ func getPlatform() string {
 	}
 }
 
-func getReleaseSource() release.Source {
+func getRetryPolicy(logger logr.Logger) retry.Policy {
+	return retry.Policy{
+		Attempts:  CLI.RetryAttempts,
+		BaseDelay: CLI.RetryDelay,
+		MaxDelay:  CLI.RetryMaxDelay,
+		Logger:    logger,
+	}
+}
+
+func getRewriteRules() ([]installer.RewriteRule, error) {
+	var rules []installer.RewriteRule
+
+	for _, s := range CLI.Rewrite {
+		rule, err := installer.ParseRewriteRule(s)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	if CLI.RewriteFile != "" {
+		fileRules, err := installer.LoadRewriteRules(CLI.RewriteFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load rewrite rules: %w", err)
+		}
+		rules = append(rules, fileRules...)
+	}
+
+	return rules, nil
+}
+
+func releaseCacheDir() string {
+	if CLI.ReleaseCacheDir != "" {
+		return CLI.ReleaseCacheDir
+	}
+
+	if userCacheDir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(userCacheDir, "unity-installer", "releases")
+	}
+
+	return ""
+}
+
+func getRevisionResolver(logger logr.Logger) release.RevisionResolver {
+	if CLI.RevisionIndex == "" {
+		return nil
+	}
+
+	index := &release.RevisionIndex{
+		Logger:     logger.WithName("revision-index"),
+		HTTPClient: http.DefaultClient,
+		URL:        CLI.RevisionIndex,
+		TTL:        CLI.ReleaseCacheTTL,
+		Retry:      getRetryPolicy(logger.WithName("retry")),
+	}
+
+	if CLI.Offline {
+		index.TTL = math.MaxInt64
+	}
+
+	if cacheDir := releaseCacheDir(); cacheDir != "" {
+		index.CacheFile = filepath.Join(cacheDir, fmt.Sprintf("revisions-%s.json", url.QueryEscape(CLI.RevisionIndex)))
+	}
+
+	return index
+}
+
+// cachedReleaseSource wraps a remote release source in a disk cache. Each
+// source and architecture gets its own cache directory, named by key.
+func cachedReleaseSource(logger logr.Logger, source release.Source, key string) release.Source {
+	cacheDir := releaseCacheDir()
+	if cacheDir == "" {
+		return source
+	}
+
+	if CLI.Arch != release.ArchX86_64 {
+		key = strings.TrimPrefix(key+"-"+CLI.Arch, "-")
+	}
+
+	if key != "" {
+		cacheDir = filepath.Join(cacheDir, url.QueryEscape(key))
+	}
+
+	diskCache, err := release.NewDiskCache(logger.WithName("release-cache"), source, cacheDir, CLI.ReleaseCacheTTL, CLI.Offline)
+	if err != nil {
+		logger.Error(err, "failed to create release cache, continuing without it")
+		return source
+	}
+
+	return diskCache
+}
+
+// newReleaseSource creates a single release source from a --release-source
+// value: "unity" for the official Hub endpoints, "unity-api" for the Unity
+// Release API, an http(s) URL for a mirror of the Hub endpoints, or a release
+// catalog directory or file:// URL.
+func newReleaseSource(logger logr.Logger, location string) (release.Source, error) {
+	if location == "unity-api" {
+		apiSource := release.DefaultAPIReleaseSource
+		apiSource.Retry = getRetryPolicy(logger.WithName("retry"))
+		apiSource.Architecture = CLI.Arch
+
+		if CLI.ReleaseAPI != "" {
+			apiSource.Endpoint = CLI.ReleaseAPI
+		}
+
+		return cachedReleaseSource(logger, &apiSource, location), nil
+	}
+
+	if location != "unity" && !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+		fileSource, err := release.NewFileReleaseSource(location)
+		if err != nil {
+			return nil, err
+		}
+
+		fileSource.Architecture = CLI.Arch
+		return fileSource, nil
+	}
+
 	releaseSource := release.DefaultReleaseSource
+	releaseSource.Retry = getRetryPolicy(logger.WithName("retry"))
+	releaseSource.Revisions = getRevisionResolver(logger)
+	releaseSource.Architecture = CLI.Arch
+
+	if location != "unity" {
+		releaseSource.PublishedVersionsEndpoint = location
+		releaseSource.GAArchiveURL = location
+		releaseSource.TestingArchiveURL = location
+		return cachedReleaseSource(logger, &releaseSource, location), nil
+	}
 
 	if CLI.ReleasesEndpoint != "" {
 		releaseSource.PublishedVersionsEndpoint = CLI.ReleasesEndpoint
@@ -61,15 +228,52 @@ func getReleaseSource() release.Source {
 		releaseSource.TestingArchiveURL = CLI.ArchiveEndpoint
 	}
 
-	return release.NewCache(&releaseSource)
+	return cachedReleaseSource(logger, &releaseSource, ""), nil
+}
+
+func getReleaseSource(logger logr.Logger) (release.Source, error) {
+	locations := CLI.ReleaseSource
+	if len(locations) == 0 && CLI.ReleaseCatalog != "" {
+		locations = []string{CLI.ReleaseCatalog}
+	} else if len(locations) == 0 && CLI.Arch != release.ArchX86_64 {
+		// The Hub endpoints only have x86_64 editors.
+		locations = []string{"unity-api"}
+	} else if len(locations) == 0 {
+		locations = []string{"unity"}
+	}
+
+	sources := make([]release.NamedSource, 0, len(locations))
+	for _, location := range locations {
+		source, err := newReleaseSource(logger, location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid release source %s: %w", location, err)
+		}
+
+		sources = append(sources, release.NamedSource{Name: location, Source: source})
+	}
+
+	return release.NewCache(release.NewMultiSource(logger.WithName("release-source"), sources...)), nil
 }
 
+// LookupTargetRelease finds the release chosen by a version selector
+// expression and revision.
 func (c commandContext) LookupTargetRelease(version, revision string) (*release.EditorRelease, error) {
-	return c.releaseSource.FetchRelease(CLI.Platform, version, revision)
+	selector, err := editor.ParseVersionSelector(version)
+	if err != nil {
+		return nil, err
+	}
+
+	editorRelease, err := release.SelectRelease(c.ctx, c.releaseSource, CLI.Platform, selector, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	c.logger.Info("resolved version", "selector", version, "version", editorRelease.Version, "revision", editorRelease.Revision)
+	return editorRelease, nil
 }
 
-func newPackageInstaller(logger logr.Logger) pkginstaller.PackageInstaller {
-	pkgInstall, err := pkginstaller.NewDefaultInstaller(logger.WithName("installer"), CLI.DryRun)
+func newPackageInstaller(logger logr.Logger, observer progress.Observer) pkginstaller.PackageInstaller {
+	pkgInstall, err := pkginstaller.NewDefaultInstaller(logger.WithName("installer"), CLI.DryRun, observer)
 	if err != nil {
 		panic(err)
 	}
@@ -78,17 +282,28 @@ func newPackageInstaller(logger logr.Logger) pkginstaller.PackageInstaller {
 }
 
 func main() {
-	logger := stdr.New(log.New(os.Stderr, "", log.LstdFlags))
+	renderer := newProgressRenderer(os.Stderr)
+	logger := stdr.New(log.New(renderer, "", log.LstdFlags))
+	renderer.logger = logger.WithName("progress")
 	pkginstaller.MaybeHandleService(logger.WithName("service"))
 
 	args := kong.Parse(&CLI, kong.Vars{
-		"default_platform": getPlatform(),
+		"default_platform":       getPlatform(),
+		"default_arch":           release.HostArchitecture(),
+		"default_revision_index": release.DefaultRevisionIndexURL,
 	})
 
+	arch, err := release.NormalizeArchitecture(CLI.Arch)
+	if err != nil {
+		logger.Error(err, "invalid architecture")
+		os.Exit(1)
+	}
+	CLI.Arch = arch
+
 	ctx, cancelCtx := context.WithCancel(context.Background())
 	defer cancelCtx()
 
-	signals := make(chan os.Signal)
+	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-signals
@@ -108,7 +323,24 @@ func main() {
 		}
 	}()
 
-	unityInstaller, err := installer.NewSimpleInstaller(logger.WithName("simple-installer"), CLI.InstallPath, tempDir, http.DefaultClient)
+	downloader := installer.NewDownloader(logger.WithName("downloader"), http.DefaultClient, tempDir)
+	downloader.Retry = getRetryPolicy(logger.WithName("retry"))
+	downloader.Observer = renderer
+	downloader.RewriteFallback = CLI.RewriteFallback
+	downloader.Rewrites, err = getRewriteRules()
+	if err != nil {
+		logger.Error(err, "invalid URL rewrite rules")
+		os.Exit(1)
+	}
+	if CLI.CacheDir != "" {
+		cache, err := installer.NewDownloadCache(logger.WithName("cache"), CLI.CacheDir, CLI.CacheMaxSize*1024*1024)
+		if err != nil {
+			panic(err)
+		}
+		downloader.Cache = cache
+	}
+
+	unityInstaller, err := installer.NewInstaller(logger.WithName("simple-installer"), CLI.InstallPath, CLI.Arch, downloader)
 	if err != nil {
 		panic(err)
 	}
@@ -118,11 +350,20 @@ func main() {
 		}
 	}()
 
+	releaseSource, err := getReleaseSource(logger)
+	if err != nil {
+		logger.Error(err, "invalid release source")
+		os.Exit(1)
+	}
+
 	cmdCtx := commandContext{
 		ctx:           ctx,
 		logger:        logger,
-		releaseSource: getReleaseSource(),
+		observer:      renderer,
+		releaseSource: releaseSource,
 		installer:     unityInstaller,
+		downloader:    downloader,
+		tempDir:       tempDir,
 	}
 	if err := args.Run(cmdCtx); err != nil {
 		logger.Error(err, "failed to run command")
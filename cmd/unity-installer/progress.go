@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/wellplayedgames/unity-installer/pkg/progress"
+)
+
+const (
+	progressBarWidth    = 30
+	progressLogInterval = 10 * time.Second
+)
+
+// progressRenderer displays progress events, as a progress bar when writing
+// to a terminal and as periodic log lines otherwise. It is also used as the
+// log output so that log lines don't collide with the progress bar.
+type progressRenderer struct {
+	out    io.Writer
+	tty    bool
+	logger logr.Logger
+
+	lock     sync.Mutex
+	active   map[string]progress.Event
+	lastLog  map[string]time.Time
+	drawnLen int
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func newProgressRenderer(f *os.File) *progressRenderer {
+	return &progressRenderer{
+		out:     f,
+		tty:     isTerminal(f),
+		active:  map[string]progress.Event{},
+		lastLog: map[string]time.Time{},
+	}
+}
+
+func (r *progressRenderer) Write(p []byte) (int, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.clear()
+	n, err := r.out.Write(p)
+	r.draw()
+	return n, err
+}
+
+func (r *progressRenderer) clear() {
+	if r.drawnLen > 0 {
+		fmt.Fprintf(r.out, "\r%s\r", strings.Repeat(" ", r.drawnLen))
+		r.drawnLen = 0
+	}
+}
+
+func (r *progressRenderer) draw() {
+	if !r.tty || len(r.active) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(r.active))
+	for key := range r.active {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var done, total int64
+	var names []string
+	for _, key := range keys {
+		e := r.active[key]
+		done += e.BytesDone
+		total += e.BytesTotal
+		names = append(names, fmt.Sprintf("%s %s", e.Phase, path.Base(e.Package)))
+	}
+
+	line := strings.Join(names, ", ")
+	if total > 0 {
+		filled := int(float64(progressBarWidth) * float64(done) / float64(total))
+		if filled > progressBarWidth {
+			filled = progressBarWidth
+		}
+
+		bar := strings.Repeat("=", filled) + strings.Repeat(" ", progressBarWidth-filled)
+		line = fmt.Sprintf("[%s] %3d%% %s / %s  %s", bar, 100*done/total, progress.FormatBytes(done), progress.FormatBytes(total), line)
+	}
+
+	fmt.Fprint(r.out, line)
+	r.drawnLen = len(line)
+}
+
+// Observe implements the progress.Observer interface.
+func (r *progressRenderer) Observe(e progress.Event) {
+	key := fmt.Sprintf("%s %s", e.Phase, e.Package)
+	logProgress := false
+
+	r.lock.Lock()
+	if e.Finished {
+		delete(r.active, key)
+		delete(r.lastLog, key)
+	} else {
+		r.active[key] = e
+
+		if !r.tty && e.BytesTotal > 0 {
+			if last, ok := r.lastLog[key]; !ok {
+				r.lastLog[key] = time.Now()
+			} else if time.Since(last) >= progressLogInterval {
+				r.lastLog[key] = time.Now()
+				logProgress = true
+			}
+		}
+	}
+
+	r.clear()
+	r.draw()
+	r.lock.Unlock()
+
+	// Log outside the lock as log output is written back through Write.
+	if r.logger == nil {
+		return
+	}
+
+	if e.Finished && e.Err == nil {
+		r.logger.Info("finished", "phase", e.Phase, "package", e.Package,
+			"bytes", e.BytesDone, "duration", e.Duration.Round(time.Millisecond).String())
+	} else if logProgress {
+		r.logger.Info("progress", "phase", e.Phase, "package", e.Package,
+			"percent", 100*e.BytesDone/e.BytesTotal, "bytes", progress.FormatBytes(e.BytesDone), "total", progress.FormatBytes(e.BytesTotal))
+	}
+}
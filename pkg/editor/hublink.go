@@ -0,0 +1,47 @@
+package editor
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var revisionRegexp = regexp.MustCompile(`^[A-Fa-f0-9]+$`)
+
+// IsValidRevision returns true if revision looks like a Unity revision hash.
+func IsValidRevision(revision string) bool {
+	return revisionRegexp.MatchString(revision)
+}
+
+// ParseHubLink parses the editor version and revision from a Unity Hub deep
+// link such as unityhub://2021.3.5f1/40eb3a945986.
+func ParseHubLink(link string) (string, string, error) {
+	link = strings.TrimSpace(link)
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid Unity Hub link %q: %w", link, err)
+	}
+
+	if u.Scheme != "unityhub" {
+		return "", "", fmt.Errorf("invalid Unity Hub link %q: expected unityhub://<version>/<revision>", link)
+	}
+
+	version := u.Host
+	revision := strings.Trim(u.Path, "/")
+
+	if version == "" || revision == "" {
+		return "", "", fmt.Errorf("invalid Unity Hub link %q: expected unityhub://<version>/<revision>", link)
+	}
+
+	if !IsValidVersion(version) || !isFullVersion(version) {
+		return "", "", fmt.Errorf("invalid Unity Hub link %q: %q is not a Unity version", link, version)
+	}
+
+	if !IsValidRevision(revision) {
+		return "", "", fmt.Errorf("invalid Unity Hub link %q: %q is not a revision hash", link, revision)
+	}
+
+	return version, strings.ToLower(revision), nil
+}
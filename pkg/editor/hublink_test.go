@@ -0,0 +1,28 @@
+package editor
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseHubLink", func() {
+	It("should parse the version and revision", func() {
+		version, revision, err := ParseHubLink("unityhub://2021.3.5f1/40eb3a945986")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(version).To(Equal("2021.3.5f1"))
+		Expect(revision).To(Equal("40eb3a945986"))
+	})
+
+	It("should reject malformed links", func() {
+		for _, link := range []string{
+			"https://2021.3.5f1/40eb3a945986",
+			"unityhub://2021.3.5f1",
+			"unityhub://2021.3.5f1/",
+			"unityhub://2021.3/40eb3a945986",
+			"unityhub://2021.3.5f1/not-a-hash",
+		} {
+			_, _, err := ParseHubLink(link)
+			Expect(err).To(HaveOccurred(), link)
+		}
+	})
+})
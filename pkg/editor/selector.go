@@ -0,0 +1,275 @@
+package editor
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Release streams which can be selected with a "latest-<stream>" keyword.
+const (
+	StreamOfficial = "official"
+	StreamLTS      = "lts"
+	StreamBeta     = "beta"
+	StreamAlpha    = "alpha"
+)
+
+var (
+	versionRegexp  = regexp.MustCompile(`^[0-9]+(\.[0-9]+){0,2}([abfp][0-9]+)?$`)
+	wildcardRegexp = regexp.MustCompile(`^[0-9]+(\.[0-9]+){0,1}\.[x*]$`)
+)
+
+type versionConstraint struct {
+	op      string
+	version string
+
+	// parts is set instead of version for partial versions such as 2022 or
+	// 2021.3, which are compared against the leading parts of a version.
+	parts []int
+}
+
+// VersionSelector matches Unity versions against an expression. An
+// expression is one of:
+//
+//	2019.4.9f1               an exact version
+//	2020.3 or 2020.3.x       any version starting with 2020.3
+//	>=2021.3.10f1 <2022      every constraint must match
+//	latest                   the newest official release
+//	latest-lts, latest-beta  the newest release in a stream
+//	latest:2022.3            the newest official release starting with 2022.3
+//
+// Apart from exact versions, alpha and beta versions only match if the
+// selector names their stream or a constraint is itself a pre-release.
+type VersionSelector struct {
+	// Stream is the release stream that matching versions must be in, or
+	// empty for any stream.
+	Stream string
+
+	exact       string
+	constraints []versionConstraint
+}
+
+// VersionStream returns the release stream of a version, based on its
+// suffix. LTS releases cannot be told apart by version, so this never returns
+// StreamLTS.
+func VersionStream(version string) string {
+	if strings.Contains(version, "a") {
+		return StreamAlpha
+	}
+
+	if strings.Contains(version, "b") {
+		return StreamBeta
+	}
+
+	return StreamOfficial
+}
+
+// IsValidVersion returns true if version looks like a (possibly partial)
+// Unity version.
+func IsValidVersion(version string) bool {
+	return versionRegexp.MatchString(version)
+}
+
+func isFullVersion(version string) bool {
+	return strings.ContainsAny(version, "abfp")
+}
+
+// versionParts returns the numeric major, minor and patch parts of a version.
+func versionParts(version string) []int {
+	var parts []int
+
+	for _, s := range strings.SplitN(version, ".", 3) {
+		if idx := strings.IndexAny(s, "abfp"); idx >= 0 {
+			s = s[:idx]
+		}
+
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+
+	return parts
+}
+
+func comparePartial(version string, parts []int) int {
+	versionParts := versionParts(version)
+
+	for idx, part := range parts {
+		if idx >= len(versionParts) {
+			return -1
+		}
+
+		if c := versionParts[idx] - part; c != 0 {
+			return c
+		}
+	}
+
+	return 0
+}
+
+func parseConstraint(term string) (versionConstraint, error) {
+	var c versionConstraint
+
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(term, op) {
+			c.op = op
+			term = term[len(op):]
+			break
+		}
+	}
+
+	if c.op == "" && wildcardRegexp.MatchString(term) {
+		c.op = "="
+		term = term[:len(term)-2]
+	}
+
+	if !IsValidVersion(term) {
+		return c, fmt.Errorf("invalid version %q", term)
+	}
+
+	if isFullVersion(term) {
+		c.version = term
+	} else {
+		c.parts = versionParts(term)
+	}
+
+	if c.op == "" {
+		c.op = "="
+	}
+
+	return c, nil
+}
+
+// ParseVersionSelector parses a version selector expression.
+func ParseVersionSelector(expr string) (*VersionSelector, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, fmt.Errorf("empty version selector")
+	}
+
+	s := &VersionSelector{}
+
+	if strings.HasPrefix(expr, "latest") {
+		rest := expr[len("latest"):]
+		prefix := ""
+		if idx := strings.IndexByte(rest, ':'); idx >= 0 {
+			rest, prefix = rest[:idx], rest[idx+1:]
+		}
+
+		switch rest {
+		case "":
+			s.Stream = StreamOfficial
+		case "-" + StreamOfficial, "-" + StreamLTS, "-" + StreamBeta, "-" + StreamAlpha:
+			s.Stream = rest[1:]
+		default:
+			return nil, fmt.Errorf("unknown release stream in %q", expr)
+		}
+
+		if prefix != "" {
+			c, err := parseConstraint(prefix)
+			if err != nil {
+				return nil, err
+			}
+			s.constraints = append(s.constraints, c)
+		}
+
+		return s, nil
+	}
+
+	terms := strings.Fields(expr)
+	if len(terms) == 1 && IsValidVersion(terms[0]) && isFullVersion(terms[0]) {
+		s.exact = terms[0]
+		return s, nil
+	}
+
+	prerelease := false
+	for _, term := range terms {
+		c, err := parseConstraint(term)
+		if err != nil {
+			return nil, err
+		}
+
+		if VersionStream(c.version) != StreamOfficial {
+			prerelease = true
+		}
+		s.constraints = append(s.constraints, c)
+	}
+
+	if !prerelease {
+		s.Stream = StreamOfficial
+	}
+
+	return s, nil
+}
+
+// Exact returns the version selected if this selector only selects a single
+// version.
+func (s *VersionSelector) Exact() (string, bool) {
+	return s.exact, s.exact != ""
+}
+
+// IncludesPrerelease returns true if this selector can match alpha or beta
+// versions.
+func (s *VersionSelector) IncludesPrerelease() bool {
+	if s.exact != "" {
+		return VersionStream(s.exact) != StreamOfficial
+	}
+
+	return s.Stream == "" || s.Stream == StreamBeta || s.Stream == StreamAlpha
+}
+
+// Match returns true if version (which is an LTS release if lts is true) is
+// selected.
+func (s *VersionSelector) Match(version string, lts bool) bool {
+	if !IsValidVersion(version) {
+		return false
+	}
+
+	if s.exact != "" {
+		return version == s.exact
+	}
+
+	switch s.Stream {
+	case "":
+	case StreamLTS:
+		if !lts || VersionStream(version) != StreamOfficial {
+			return false
+		}
+	default:
+		if VersionStream(version) != s.Stream {
+			return false
+		}
+	}
+
+	for _, c := range s.constraints {
+		var cmp int
+		if c.parts != nil {
+			cmp = comparePartial(version, c.parts)
+		} else {
+			cmp = CompareVersions(version, c.version)
+		}
+
+		var ok bool
+		switch c.op {
+		case ">=":
+			ok = cmp >= 0
+		case "<=":
+			ok = cmp <= 0
+		case ">":
+			ok = cmp > 0
+		case "<":
+			ok = cmp < 0
+		default:
+			ok = cmp == 0
+		}
+
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
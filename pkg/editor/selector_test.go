@@ -0,0 +1,69 @@
+package editor
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("VersionSelector", func() {
+	parse := func(expr string) *VersionSelector {
+		s, err := ParseVersionSelector(expr)
+		Expect(err).NotTo(HaveOccurred())
+		return s
+	}
+
+	It("should match exact versions", func() {
+		s := parse("2019.4.9f1")
+		v, ok := s.Exact()
+		Expect(ok).To(BeTrue())
+		Expect(v).To(Equal("2019.4.9f1"))
+		Expect(s.Match("2019.4.9f1", false)).To(BeTrue())
+		Expect(s.Match("2019.4.9f10", false)).To(BeFalse())
+	})
+
+	It("should match wildcards and partial versions", func() {
+		for _, expr := range []string{"2020.3.x", "2020.3.*", "2020.3"} {
+			s := parse(expr)
+			Expect(s.Match("2020.3.10f1", false)).To(BeTrue(), expr)
+			Expect(s.Match("2020.30.1f1", false)).To(BeFalse(), expr)
+			Expect(s.Match("2020.3.0b1", false)).To(BeFalse(), expr)
+		}
+	})
+
+	It("should match ranges", func() {
+		s := parse(">=2021.3.10f1 <2022")
+		Expect(s.Match("2021.3.10f1", false)).To(BeTrue())
+		Expect(s.Match("2021.3.31f1", false)).To(BeTrue())
+		Expect(s.Match("2021.3.9f1", false)).To(BeFalse())
+		Expect(s.Match("2022.1.0f1", false)).To(BeFalse())
+
+		s = parse(">=2019.4.9f1")
+		Expect(s.Match("2019.4.10p1", false)).To(BeTrue())
+		Expect(s.Match("2019.4.9f10", false)).To(BeTrue())
+		Expect(s.Match("2019.4.9b14", false)).To(BeFalse())
+
+		s = parse("<=2021.3")
+		Expect(s.Match("2021.3.45f1", false)).To(BeTrue())
+		Expect(s.Match("2021.4.0f1", false)).To(BeFalse())
+	})
+
+	It("should match streams", func() {
+		Expect(parse("latest-lts").Match("2021.3.1f1", true)).To(BeTrue())
+		Expect(parse("latest-lts").Match("2022.1.1f1", false)).To(BeFalse())
+		Expect(parse("latest-beta").Match("2023.1.0b1", false)).To(BeTrue())
+		Expect(parse("latest-beta").Match("2023.1.0f1", false)).To(BeFalse())
+		Expect(parse("latest").Match("2023.1.0a1", false)).To(BeFalse())
+
+		s := parse("latest:2022.3")
+		Expect(s.Match("2022.3.5f1", true)).To(BeTrue())
+		Expect(s.Match("2022.2.5f1", false)).To(BeFalse())
+		Expect(s.IncludesPrerelease()).To(BeFalse())
+	})
+
+	It("should reject invalid expressions", func() {
+		for _, expr := range []string{"", "latest-nightly", "2020.3.x.x", ">=banana"} {
+			_, err := ParseVersionSelector(expr)
+			Expect(err).To(HaveOccurred(), expr)
+		}
+	})
+})
@@ -7,7 +7,9 @@ import (
 )
 
 const (
-	versionSplitChars = ".abf"
+	// versionSplitChars separates the parts of a version, in the order they
+	// rank: alpha releases come before beta, official and patch releases.
+	versionSplitChars = ".abfp"
 )
 
 // CompareVersions compares two Unity editor versions.
@@ -29,8 +31,8 @@ func CompareVersions(a, b string) int {
 
 		aPart := a[:idxA]
 		bPart := b[:idxB]
-		aSep := int(a[idxA])
-		bSep := int(b[idxB])
+		aSep := strings.IndexByte(versionSplitChars, a[idxA])
+		bSep := strings.IndexByte(versionSplitChars, b[idxB])
 		a = a[idxA+1:]
 		b = b[idxB+1:]
 
@@ -53,5 +55,11 @@ func CompareVersions(a, b string) int {
 		}
 	}
 
+	aNum, aErr := strconv.Atoi(a)
+	bNum, bErr := strconv.Atoi(b)
+	if aErr == nil && bErr == nil {
+		return aNum - bNum
+	}
+
 	return strings.Compare(a, b)
 }
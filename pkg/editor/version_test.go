@@ -2,6 +2,7 @@ package editor
 
 import (
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 )
 
@@ -12,4 +13,18 @@ var _ = Describe("CompareVersions", func() {
 		Expect(CompareVersions("2019.1.3a0", "2019.1.3b20")).To(BeNumerically("<", 0))
 		Expect(CompareVersions("2019.4.9f1", "2019.4.14f1")).To(BeNumerically("<", 0))
 	})
-})
\ No newline at end of file
+
+	DescribeTable("should order versions",
+		func(older, newer string) {
+			Expect(CompareVersions(older, newer)).To(BeNumerically("<", 0))
+			Expect(CompareVersions(newer, older)).To(BeNumerically(">", 0))
+		},
+		Entry("build numbers", "2019.4.9f2", "2019.4.9f10"),
+		Entry("beta build numbers", "2020.1.0b2", "2020.1.0b14"),
+		Entry("alpha before beta", "2020.1.0a25", "2020.1.0b1"),
+		Entry("beta before official", "2020.1.0b14", "2020.1.0f1"),
+		Entry("official before patch", "2019.4.9f1", "2019.4.9p1"),
+		Entry("patch releases", "2019.4.9f1", "2019.4.10p1"),
+		Entry("patch build numbers", "2019.4.9p2", "2019.4.9p10"),
+	)
+})
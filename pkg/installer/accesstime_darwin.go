@@ -0,0 +1,19 @@
+// +build darwin
+
+package installer
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// accessTime returns the time a file was last accessed, or its modification
+// time if that is not known.
+func accessTime(info os.FileInfo) time.Time {
+	if sys, ok := info.Sys().(*syscall.Stat_t); ok {
+		return time.Unix(int64(sys.Atimespec.Sec), int64(sys.Atimespec.Nsec))
+	}
+
+	return info.ModTime()
+}
@@ -0,0 +1,19 @@
+// +build linux
+
+package installer
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// accessTime returns the time a file was last accessed, or its modification
+// time if that is not known.
+func accessTime(info os.FileInfo) time.Time {
+	if sys, ok := info.Sys().(*syscall.Stat_t); ok {
+		return time.Unix(int64(sys.Atim.Sec), int64(sys.Atim.Nsec))
+	}
+
+	return info.ModTime()
+}
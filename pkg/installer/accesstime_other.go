@@ -0,0 +1,14 @@
+// +build !linux,!darwin,!windows
+
+package installer
+
+import (
+	"os"
+	"time"
+)
+
+// accessTime returns the modification time of a file, since access times
+// are not available on this platform.
+func accessTime(info os.FileInfo) time.Time {
+	return info.ModTime()
+}
@@ -0,0 +1,19 @@
+// +build windows
+
+package installer
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// accessTime returns the time a file was last accessed, or its modification
+// time if that is not known.
+func accessTime(info os.FileInfo) time.Time {
+	if sys, ok := info.Sys().(*syscall.Win32FileAttributeData); ok {
+		return time.Unix(0, sys.LastAccessTime.Nanoseconds())
+	}
+
+	return info.ModTime()
+}
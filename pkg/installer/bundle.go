@@ -0,0 +1,217 @@
+package installer
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/wellplayedgames/unity-installer/pkg/release"
+)
+
+const (
+	// BundleSpecFile is the name of the install spec within a bundle.
+	BundleSpecFile = "spec.json"
+
+	bundlePackagesDir = "packages"
+)
+
+// CreateBundle downloads the editor and selected modules of an install spec
+// into dir, along with a copy of the spec whose package URLs are relative to
+// dir. The bundle can then be installed without network access.
+func CreateBundle(ctx context.Context, downloader *Downloader, spec *release.EditorRelease, dir string, parallelDownloads int) error {
+	bundleSpec := *spec
+	bundleSpec.Modules = append([]release.ModuleRelease(nil), spec.Modules...)
+
+	pkgs := []*release.Package{&bundleSpec.Package}
+	for idx := range bundleSpec.Modules {
+		if bundleSpec.Modules[idx].Selected {
+			pkgs = append(pkgs, &bundleSpec.Modules[idx].Package)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, bundlePackagesDir), os.ModePerm); err != nil {
+		return err
+	}
+
+	relPaths := make([]string, len(pkgs))
+	usedNames := map[string]bool{}
+	for idx, pkg := range pkgs {
+		_, name := path.Split(pkg.DownloadURL)
+		if usedNames[name] {
+			name = fmt.Sprintf("%d-%s", idx, name)
+		}
+		usedNames[name] = true
+		relPaths[idx] = path.Join(bundlePackagesDir, name)
+	}
+
+	targetPaths := map[*release.Package]string{}
+	for idx, pkg := range pkgs {
+		targetPaths[pkg] = filepath.Join(dir, filepath.FromSlash(relPaths[idx]))
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	pipeline := startDownloadPipeline(ctx, func(ctx context.Context, pkg *release.Package) (string, error) {
+		targetPath := targetPaths[pkg]
+		if checkFileExists(targetPath) {
+			verifier := newPackageVerifier(pkg)
+			if err := copyFileTo(targetPath, verifier); err == nil && verifier.Verify() == nil {
+				return targetPath, nil
+			}
+		}
+
+		return targetPath, downloader.DownloadTo(ctx, pkg, targetPath)
+	}, pkgs, parallelDownloads)
+	defer func() {
+		cancel()
+		pipeline.Close()
+	}()
+
+	for idx := range pkgs {
+		if _, err := pipeline.Wait(ctx, idx); err != nil {
+			return err
+		}
+	}
+
+	for idx, pkg := range pkgs {
+		pkg.DownloadURL = relPaths[idx]
+	}
+
+	b, err := json.MarshalIndent(&bundleSpec, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(dir, BundleSpecFile), b, 0666)
+}
+
+// OpenBundle reads the install spec from a bundle directory, pointing its
+// bundled packages at local file:// URLs.
+func OpenBundle(dir string) (*release.EditorRelease, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(absDir, BundleSpecFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle spec: %w", err)
+	}
+
+	spec := &release.EditorRelease{}
+	if err := json.Unmarshal(b, spec); err != nil {
+		return nil, fmt.Errorf("failed to decode bundle spec: %w", err)
+	}
+
+	localise := func(pkg *release.Package) {
+		if pkg.DownloadURL != "" && !strings.Contains(pkg.DownloadURL, "://") {
+			pkg.DownloadURL = FileURL(filepath.Join(absDir, filepath.FromSlash(pkg.DownloadURL)))
+		}
+	}
+
+	localise(&spec.Package)
+	for idx := range spec.Modules {
+		localise(&spec.Modules[idx].Package)
+	}
+
+	return spec, nil
+}
+
+// WriteBundleTar packs a bundle directory into a single tar file.
+func WriteBundleTar(dir, tarPath string) (err error) {
+	f, err := os.Create(tarPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	w := tar.NewWriter(f)
+	err = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+
+		if err := w.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		return copyFileTo(p, w)
+	})
+	if err != nil {
+		return err
+	}
+
+	return w.Close()
+}
+
+// ExtractBundleTar unpacks a bundle tar file into dir.
+func ExtractBundleTar(tarPath, dir string) error {
+	f, err := os.Open(tarPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := tar.NewReader(f)
+	for {
+		hdr, err := r.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		targetPath := filepath.Join(dir, filepath.FromSlash(hdr.Name))
+		if !isWithinDir(dir, targetPath) {
+			return fmt.Errorf("bundle entry %s escapes the bundle", hdr.Name)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(targetPath), os.ModePerm); err != nil {
+			return err
+		}
+
+		w, err := os.Create(targetPath)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(w, r)
+		if cerr := w.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// isWithinDir returns true if p is dir or a path inside it.
+func isWithinDir(dir, p string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(p))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,77 @@
+package installer
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/wellplayedgames/unity-installer/pkg/release"
+)
+
+var _ = Describe("Bundle", func() {
+	var (
+		tempDir string
+		server  *testPackageServer
+		content []byte
+		spec    *release.EditorRelease
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "bundle-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		content = bytes.Repeat([]byte("bundle"), 1024)
+		server = newTestPackageServer(content, true, 0)
+
+		sum := md5.Sum(content)
+		pkg := release.Package{DownloadURL: server.URL + "/Unity.zip", DownloadSize: release.Size(len(content))}
+		pkg.Checksum = hex.EncodeToString(sum[:])
+
+		spec = &release.EditorRelease{Package: pkg, Version: "2019.4.1f1"}
+		spec.Modules = []release.ModuleRelease{
+			{Package: pkg, ID: "android", Selected: true},
+			{Package: pkg, ID: "ios"},
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+		Expect(os.RemoveAll(tempDir)).To(Succeed())
+	})
+
+	It("should install from a bundle without the network", func() {
+		downloader := NewDownloader(logrtesting.NullLogger{}, http.DefaultClient, tempDir)
+		bundleDir := filepath.Join(tempDir, "bundle")
+		Expect(CreateBundle(context.Background(), downloader, spec, bundleDir, 2)).To(Succeed())
+		Expect(spec.DownloadURL).To(HavePrefix(server.URL))
+		server.Close()
+
+		tarPath := filepath.Join(tempDir, "bundle.tar")
+		Expect(WriteBundleTar(bundleDir, tarPath)).To(Succeed())
+		extractDir := filepath.Join(tempDir, "extracted")
+		Expect(ExtractBundleTar(tarPath, extractDir)).To(Succeed())
+
+		bundled, err := OpenBundle(extractDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bundled.Version).To(Equal(spec.Version))
+		Expect(bundled.DownloadURL).To(HavePrefix("file://"))
+		Expect(bundled.Modules[0].DownloadURL).To(HavePrefix("file://"))
+		Expect(bundled.Modules[0].DownloadURL).NotTo(Equal(bundled.DownloadURL))
+		Expect(bundled.Modules[1].DownloadURL).To(HavePrefix(server.URL))
+
+		for _, pkg := range []*release.Package{&bundled.Package, &bundled.Modules[0].Package} {
+			path, err := downloader.Download(context.Background(), pkg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ioutil.ReadFile(path)).To(Equal(content))
+		}
+	})
+})
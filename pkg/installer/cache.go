@@ -0,0 +1,297 @@
+package installer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/wellplayedgames/unity-installer/pkg/release"
+)
+
+const (
+	cacheLockSuffix = ".lock"
+	cacheUseSuffix  = ".use"
+	cacheEvictLock  = "evict" + cacheLockSuffix
+
+	// cacheGracePeriod protects recently used entries from eviction so that
+	// another process can install a package it has just looked up.
+	cacheGracePeriod = 10 * time.Minute
+)
+
+// DownloadCache is a persistent, content-addressed store of downloaded
+// packages. Entries are keyed by download URL and checksum, and the least
+// recently used entries are evicted once the cache grows beyond its maximum
+// size.
+//
+// The cache may be shared between processes: entries are locked whilst being
+// downloaded and are only made visible once complete. Entries which have been
+// fetched are marked as in use, and so are never evicted, until the cache is
+// closed.
+type DownloadCache struct {
+	logger  logr.Logger
+	dir     string
+	maxSize int64
+
+	lock sync.Mutex
+	uses map[string]*fileLock
+}
+
+type cacheEntry struct {
+	key     string
+	size    int64
+	lastUse time.Time
+}
+
+// NewDownloadCache creates a download cache in dir. If maxSize is greater
+// than zero, least recently used entries are evicted to keep the total size
+// of the cache below it.
+func NewDownloadCache(logger logr.Logger, dir string, maxSize int64) (*DownloadCache, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	return &DownloadCache{
+		logger:  logger,
+		dir:     dir,
+		maxSize: maxSize,
+		uses:    map[string]*fileLock{},
+	}, nil
+}
+
+// Close releases the entries this process has fetched, allowing them to be
+// evicted.
+func (c *DownloadCache) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var firstErr error
+	for key, use := range c.uses {
+		if err := use.Unlock(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(c.uses, key)
+	}
+
+	return firstErr
+}
+
+// usePath returns a unique path for this process's in use marker for an
+// entry.
+func (c *DownloadCache) usePath(key string) string {
+	return filepath.Join(c.dir, fmt.Sprintf("%s.%d-%d%s", key, os.Getpid(), time.Now().UnixNano(), cacheUseSuffix))
+}
+
+// markInUse protects an entry from eviction until the cache is closed.
+func (c *DownloadCache) markInUse(key string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.uses[key] != nil {
+		return nil
+	}
+
+	use, err := tryLockFile(c.usePath(key))
+	if err != nil {
+		return err
+	} else if use == nil {
+		return fmt.Errorf("cache entry %s is already marked as in use", key)
+	}
+
+	c.uses[key] = use
+	return nil
+}
+
+// isInUse returns true if any process is using an entry. Markers left by
+// crashed processes are removed.
+func (c *DownloadCache) isInUse(key string) bool {
+	markers, err := filepath.Glob(filepath.Join(c.dir, key+".*"+cacheUseSuffix))
+	if err != nil {
+		return true
+	}
+
+	inUse := false
+	for _, marker := range markers {
+		if isLocked(marker) {
+			inUse = true
+		} else if err := breakStaleLock(marker); err != nil {
+			c.logger.Error(err, "failed to remove stale cache entry marker", "path", marker)
+		}
+	}
+
+	return inUse
+}
+
+func cacheKey(pkg *release.Package) string {
+	checksum := pkg.SHA256
+	if checksum == "" {
+		checksum = pkg.Integrity
+	}
+	if checksum == "" {
+		checksum = pkg.Checksum
+	}
+
+	h := sha256.New()
+	h.Write([]byte(pkg.DownloadURL))
+	h.Write([]byte{'\n'})
+	h.Write([]byte(strings.ToLower(checksum)))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *DownloadCache) entryPath(key string, pkg *release.Package) string {
+	_, fileName := path.Split(pkg.DownloadURL)
+	return filepath.Join(c.dir, key, fileName)
+}
+
+// Lookup returns the cached path of a package if it is present.
+func (c *DownloadCache) Lookup(pkg *release.Package) (string, bool) {
+	entryPath := c.entryPath(cacheKey(pkg), pkg)
+	if !checkFileExists(entryPath) {
+		return "", false
+	}
+
+	now := time.Now()
+	if err := os.Chtimes(entryPath, now, now); err != nil {
+		c.logger.Error(err, "failed to update cache entry access time", "path", entryPath)
+	}
+
+	return entryPath, true
+}
+
+// Fetch returns the cached path of a package, calling download to populate
+// the cache if the package is not yet present.
+func (c *DownloadCache) Fetch(ctx context.Context, pkg *release.Package, download func(targetPath string) error) (string, error) {
+	key := cacheKey(pkg)
+
+	lock, err := lockFile(ctx, filepath.Join(c.dir, key+cacheLockSuffix))
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := lock.Unlock(); err != nil {
+			c.logger.Error(err, "failed to unlock cache entry", "key", key)
+		}
+	}()
+
+	if entryPath, ok := c.Lookup(pkg); ok {
+		c.logger.Info("using cached package", "package", pkg.DownloadURL, "path", entryPath)
+		return entryPath, c.markInUse(key)
+	}
+
+	entryPath := c.entryPath(key, pkg)
+	if err := os.MkdirAll(filepath.Dir(entryPath), os.ModePerm); err != nil {
+		return "", err
+	}
+
+	if err := download(entryPath); err != nil {
+		return "", err
+	}
+
+	if err := c.markInUse(key); err != nil {
+		return "", err
+	}
+
+	if err := c.evict(key); err != nil {
+		c.logger.Error(err, "failed to evict old cache entries")
+	}
+
+	return entryPath, nil
+}
+
+func (c *DownloadCache) scan() ([]cacheEntry, int64, error) {
+	infos, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var entries []cacheEntry
+	var total int64
+
+	for _, info := range infos {
+		if !info.IsDir() {
+			continue
+		}
+
+		entry := cacheEntry{key: info.Name()}
+		err := filepath.Walk(filepath.Join(c.dir, entry.key), func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+
+			entry.size += info.Size()
+			if info.ModTime().After(entry.lastUse) {
+				entry.lastUse = info.ModTime()
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+
+		total += entry.size
+		entries = append(entries, entry)
+	}
+
+	return entries, total, nil
+}
+
+// evict removes least recently used entries until the cache fits within its
+// maximum size. Entries which are locked, in use, recently used or equal to
+// keep are never removed.
+func (c *DownloadCache) evict(keep string) error {
+	if c.maxSize <= 0 {
+		return nil
+	}
+
+	lock, err := tryLockFile(filepath.Join(c.dir, cacheEvictLock))
+	if lock == nil || err != nil {
+		// Another process is already evicting.
+		return err
+	}
+	defer func() {
+		if err := lock.Unlock(); err != nil {
+			c.logger.Error(err, "failed to unlock cache eviction")
+		}
+	}()
+
+	entries, total, err := c.scan()
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].lastUse.Before(entries[j].lastUse)
+	})
+
+	for _, entry := range entries {
+		if total <= c.maxSize {
+			break
+		}
+
+		if entry.key == keep ||
+			time.Since(entry.lastUse) < cacheGracePeriod ||
+			isLocked(filepath.Join(c.dir, entry.key+cacheLockSuffix)) ||
+			c.isInUse(entry.key) {
+			continue
+		}
+
+		c.logger.Info("evicting cached package", "key", entry.key, "size", entry.size)
+		if err := os.RemoveAll(filepath.Join(c.dir, entry.key)); err != nil {
+			c.logger.Error(err, "failed to evict cache entry", "key", entry.key)
+			continue
+		}
+
+		total -= entry.size
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package installer
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/wellplayedgames/unity-installer/pkg/release"
+)
+
+var _ = Describe("DownloadCache", func() {
+	var (
+		tempDir string
+		cache   *DownloadCache
+	)
+
+	pkg := func(name string) *release.Package {
+		return &release.Package{DownloadURL: "https://example.com/" + name}
+	}
+
+	fetch := func(p *release.Package, size int) string {
+		entryPath, err := cache.Fetch(context.Background(), p, func(targetPath string) error {
+			return ioutil.WriteFile(targetPath, make([]byte, size), 0644)
+		})
+		Expect(err).NotTo(HaveOccurred())
+		return entryPath
+	}
+
+	// age makes an entry look like it was last used d ago.
+	age := func(entryPath string, d time.Duration) {
+		t := time.Now().Add(-d)
+		Expect(os.Chtimes(entryPath, t, t)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "cache-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		cache, err = NewDownloadCache(logrtesting.NullLogger{}, tempDir, 25)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(cache.Close()).To(Succeed())
+		Expect(os.RemoveAll(tempDir)).To(Succeed())
+	})
+
+	It("should evict the least recently used entries beyond the size limit", func() {
+		oldest := fetch(pkg("a.zip"), 10)
+		older := fetch(pkg("b.zip"), 10)
+		Expect(cache.Close()).To(Succeed())
+		age(oldest, 2*time.Hour)
+		age(older, time.Hour)
+
+		newest := fetch(pkg("c.zip"), 10)
+		Expect(oldest).NotTo(BeAnExistingFile())
+		Expect(older).To(BeAnExistingFile())
+		Expect(newest).To(BeAnExistingFile())
+	})
+
+	It("should not evict entries used within the grace period", func() {
+		recent := fetch(pkg("a.zip"), 20)
+		Expect(cache.Close()).To(Succeed())
+		age(recent, cacheGracePeriod/2)
+
+		fetch(pkg("b.zip"), 20)
+		Expect(recent).To(BeAnExistingFile())
+	})
+
+	It("should not evict entries which are in use", func() {
+		inUse := fetch(pkg("a.zip"), 20)
+		age(inUse, time.Hour)
+
+		fetch(pkg("b.zip"), 20)
+		Expect(inUse).To(BeAnExistingFile())
+
+		// Once released, the entry can be evicted.
+		Expect(cache.Close()).To(Succeed())
+		age(inUse, time.Hour)
+		fetch(pkg("c.zip"), 10)
+		Expect(inUse).NotTo(BeAnExistingFile())
+	})
+
+	It("should serve cached entries without downloading again", func() {
+		first := fetch(pkg("a.zip"), 10)
+
+		entryPath, err := cache.Fetch(context.Background(), pkg("a.zip"), func(string) error {
+			Fail("package should not be downloaded again")
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entryPath).To(Equal(first))
+	})
+})
+
+var _ = Describe("fileLock", func() {
+	var tempDir string
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "lock-test")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tempDir)).To(Succeed())
+	})
+
+	It("should not take a lock which is held", func() {
+		lockPath := filepath.Join(tempDir, "entry.lock")
+		held, err := tryLockFile(lockPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(held).NotTo(BeNil())
+		defer func() { Expect(held.Unlock()).To(Succeed()) }()
+
+		l, err := tryLockFile(lockPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(l).To(BeNil())
+		Expect(isLocked(lockPath)).To(BeTrue())
+	})
+
+	It("should break stale locks", func() {
+		lockPath := filepath.Join(tempDir, "entry.lock")
+		Expect(ioutil.WriteFile(lockPath, []byte("1\n"), 0644)).To(Succeed())
+		stale := time.Now().Add(-2 * lockStaleAfter)
+		Expect(os.Chtimes(lockPath, stale, stale)).To(Succeed())
+		Expect(isLocked(lockPath)).To(BeFalse())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		l, err := lockFile(ctx, lockPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(l).NotTo(BeNil())
+		Expect(l.Unlock()).To(Succeed())
+		Expect(lockPath).NotTo(BeAnExistingFile())
+	})
+	It("should not remove a lock taken by another process", func() {
+		lockPath := filepath.Join(tempDir, "entry.lock")
+		l, err := tryLockFile(lockPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(l).NotTo(BeNil())
+
+		// Another process breaks the lock and takes it.
+		Expect(ioutil.WriteFile(lockPath, []byte("1-2-3\n"), 0644)).To(Succeed())
+
+		Expect(l.Unlock()).To(MatchError(ContainSubstring("was broken")))
+		Expect(readLockOwner(lockPath)).To(Equal("1-2-3"))
+	})
+
+	It("should only break the stale lock it found", func() {
+		lockPath := filepath.Join(tempDir, "entry.lock")
+		Expect(ioutil.WriteFile(lockPath, []byte("1-2-3\n"), 0644)).To(Succeed())
+
+		removed, err := removeLockIfOwner(lockPath, "4-5-6")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(removed).To(BeFalse())
+		Expect(readLockOwner(lockPath)).To(Equal("1-2-3"))
+
+		removed, err = removeLockIfOwner(lockPath, "1-2-3")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(removed).To(BeTrue())
+		Expect(lockPath).NotTo(BeAnExistingFile())
+
+		files, err := ioutil.ReadDir(tempDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(BeEmpty())
+	})
+})
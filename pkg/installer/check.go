@@ -0,0 +1,243 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	packageinstaller "github.com/wellplayedgames/unity-installer/pkg/package-installer"
+	"github.com/wellplayedgames/unity-installer/pkg/release"
+)
+
+// Problems found with installed files.
+const (
+	FileMissing  = "missing"
+	FileModified = "modified"
+	FileExtra    = "extra"
+)
+
+// FileProblem is an installed file which doesn't match its package's
+// manifest.
+type FileProblem struct {
+	// Package is the name of the manifest the file is in, which is empty for
+	// extra files.
+	Package string `json:"package,omitempty"`
+	Path    string `json:"path"`
+	Problem string `json:"problem"`
+}
+
+// InstallReport is the result of checking an installed editor.
+type InstallReport struct {
+	Problems []FileProblem `json:"problems"`
+
+	// Unverified lists installed packages which have no manifest, so their
+	// files could not be checked. Extra files are only looked for if every
+	// package was checked.
+	Unverified []string `json:"unverified,omitempty"`
+}
+
+// DamagedPackages returns the names of the packages with missing or modified
+// files.
+func (r *InstallReport) DamagedPackages() []string {
+	seen := map[string]bool{}
+	var names []string
+
+	for _, p := range r.Problems {
+		if p.Package != "" && !seen[p.Package] {
+			seen[p.Package] = true
+			names = append(names, p.Package)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func fileCRC32(path string) (uint32, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	h := crc32.NewIEEE()
+	if _, err := io.Copy(h, f); err != nil {
+		return 0, err
+	}
+
+	return h.Sum32(), nil
+}
+
+// checkFile returns the problem with an installed file, or "" if it matches
+// its manifest entry.
+func checkFile(editorPath string, f *packageinstaller.ManifestFile) (string, error) {
+	filePath := filepath.Join(editorPath, filepath.FromSlash(f.Path))
+
+	info, err := os.Lstat(filePath)
+	if os.IsNotExist(err) {
+		return FileMissing, nil
+	} else if err != nil {
+		return "", err
+	}
+
+	if f.Mode != 0 && info.Mode() != f.Mode {
+		return FileModified, nil
+	}
+
+	if f.Link != "" {
+		link, err := os.Readlink(filePath)
+		if err != nil || link != f.Link {
+			return FileModified, nil
+		}
+		return "", nil
+	}
+
+	if !info.Mode().IsRegular() || info.Size() != f.Size {
+		return FileModified, nil
+	}
+
+	switch {
+	case f.SHA256 != "":
+		sum, err := packageinstaller.HashFile(filePath)
+		if err != nil {
+			return "", err
+		}
+		if sum != f.SHA256 {
+			return FileModified, nil
+		}
+	case f.CRC32 != 0:
+		sum, err := fileCRC32(filePath)
+		if err != nil {
+			return "", err
+		}
+		if sum != f.CRC32 {
+			return FileModified, nil
+		}
+	}
+
+	return "", nil
+}
+
+// zipModuleManifest builds a manifest for a module which was installed
+// without one, from the directory of its zip package. It returns nil if the
+// module is not a zip package.
+func zipModuleManifest(ctx context.Context, unityInstaller UnityInstaller, editorPath string, m *release.ModuleRelease) (*packageinstaller.Manifest, error) {
+	if !strings.EqualFold(path.Ext(m.DownloadURL), ".zip") {
+		return nil, nil
+	}
+
+	packagePath, err := unityInstaller.DownloadPackage(ctx, &m.Package)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s to check it: %w", m.ID, err)
+	}
+
+	return packageinstaller.ZipManifest(m.ID, packagePath, editorPath, m.InstallOptions)
+}
+
+// CheckEditorInstall compares the files of an installed editor and its
+// modules against the manifests recorded when they were installed. Modules
+// installed without a manifest are checked against their zip package's
+// directory where possible, downloading the package if needed.
+func CheckEditorInstall(ctx context.Context, unityInstaller UnityInstaller, editorVersion string) (*InstallReport, error) {
+	found, modules, err := unityInstaller.CheckEditorVersion(editorVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, fmt.Errorf("Unity %s is not installed", editorVersion)
+	}
+
+	editorPath := unityInstaller.EditorPath(editorVersion)
+	manifests, err := packageinstaller.ReadManifests(editorPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifests: %w", err)
+	}
+
+	report := &InstallReport{}
+	if manifests[packageinstaller.EditorManifest] == nil {
+		report.Unverified = append(report.Unverified, packageinstaller.EditorManifest)
+	}
+
+	for idx := range modules {
+		m := &modules[idx]
+		if !m.Selected || manifests[m.ID] != nil {
+			continue
+		}
+
+		manifest, err := zipModuleManifest(ctx, unityInstaller, editorPath, m)
+		if err != nil {
+			return nil, err
+		}
+
+		if manifest == nil {
+			report.Unverified = append(report.Unverified, m.ID)
+			continue
+		}
+		manifests[m.ID] = manifest
+	}
+
+	names := make([]string, 0, len(manifests))
+	for name := range manifests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	owned := map[string]bool{}
+
+	for _, name := range names {
+		for idx := range manifests[name].Files {
+			f := &manifests[name].Files[idx]
+			owned[f.Path] = true
+
+			problem, err := checkFile(editorPath, f)
+			if err != nil {
+				return nil, err
+			}
+
+			if problem != "" {
+				report.Problems = append(report.Problems, FileProblem{Package: name, Path: f.Path, Problem: problem})
+			}
+		}
+	}
+
+	if len(report.Unverified) > 0 {
+		return report, nil
+	}
+
+	err = filepath.Walk(editorPath, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(editorPath, filePath)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if packageinstaller.IsStatePath(rel) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !info.IsDir() && !owned[rel] {
+			report.Problems = append(report.Problems, FileProblem{Path: rel, Problem: FileExtra})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", editorPath, err)
+	}
+
+	return report, nil
+}
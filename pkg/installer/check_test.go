@@ -0,0 +1,129 @@
+package installer
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	packageinstaller "github.com/wellplayedgames/unity-installer/pkg/package-installer"
+	"github.com/wellplayedgames/unity-installer/pkg/release"
+)
+
+var _ = Describe("CheckEditorInstall", func() {
+	var (
+		tempDir    string
+		editorPath string
+		installer  *simpleInstaller
+	)
+
+	writeFile := func(relPath, content string) {
+		p := filepath.Join(editorPath, filepath.FromSlash(relPath))
+		Expect(os.MkdirAll(filepath.Dir(p), os.ModePerm)).To(Succeed())
+		Expect(ioutil.WriteFile(p, []byte(content), 0644)).To(Succeed())
+	}
+
+	writeManifest := func(name string, paths ...string) {
+		manifest := &packageinstaller.Manifest{Name: name}
+		for _, p := range paths {
+			entry, err := packageinstaller.DescribeFile(editorPath, p)
+			Expect(err).NotTo(HaveOccurred())
+			manifest.Files = append(manifest.Files, entry)
+		}
+
+		b, err := json.Marshal(manifest)
+		Expect(err).NotTo(HaveOccurred())
+		writeFile(packageinstaller.ManifestsDir+"/"+name+".json", string(b))
+	}
+
+	writeModules := func(modules ...release.ModuleRelease) {
+		b, err := json.Marshal(modules)
+		Expect(err).NotTo(HaveOccurred())
+		writeFile(packageinstaller.ModulesFile, string(b))
+	}
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "check-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		editorPath = filepath.Join(tempDir, "editors", "2019.4.9f1")
+		installer = &simpleInstaller{
+			downloader: NewDownloader(nil, nil, tempDir),
+			editorDir:  filepath.Join(tempDir, "editors"),
+		}
+
+		writeFile("Editor/Unity", "unity")
+		writeFile("Editor/Data/Managed/UnityEngine.dll", "engine")
+		writeManifest(packageinstaller.EditorManifest, "Editor/Unity", "Editor/Data/Managed/UnityEngine.dll")
+		writeModules()
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tempDir)).To(Succeed())
+	})
+
+	It("should accept an intact install", func() {
+		report, err := CheckEditorInstall(context.Background(), installer, "2019.4.9f1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Problems).To(BeEmpty())
+		Expect(report.Unverified).To(BeEmpty())
+	})
+
+	It("should report missing, modified and extra files", func() {
+		writeFile("Editor/Unity", "UNITY")
+		Expect(os.Remove(filepath.Join(editorPath, "Editor", "Data", "Managed", "UnityEngine.dll"))).To(Succeed())
+		writeFile("Editor/Data/Managed/Extra.dll", "extra")
+
+		report, err := CheckEditorInstall(context.Background(), installer, "2019.4.9f1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Problems).To(ConsistOf(
+			FileProblem{Package: "editor", Path: "Editor/Unity", Problem: FileModified},
+			FileProblem{Package: "editor", Path: "Editor/Data/Managed/UnityEngine.dll", Problem: FileMissing},
+			FileProblem{Path: "Editor/Data/Managed/Extra.dll", Problem: FileExtra},
+		))
+		Expect(report.DamagedPackages()).To(Equal([]string{"editor"}))
+	})
+
+	It("should check modules without manifests against their zip package", func() {
+		zipPath := filepath.Join(tempDir, "webgl.zip")
+		f, err := os.Create(zipPath)
+		Expect(err).NotTo(HaveOccurred())
+		w := zip.NewWriter(f)
+		fw, err := w.Create("ivy.xml")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = fw.Write([]byte("webgl"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(w.Close()).To(Succeed())
+		Expect(f.Close()).To(Succeed())
+
+		destination := "{UNITY_PATH}/Editor/Data/PlaybackEngines/WebGLSupport"
+		webgl := release.ModuleRelease{ID: "webgl", Selected: true}
+		webgl.DownloadURL = "file://" + filepath.ToSlash(zipPath)
+		webgl.Destination = &destination
+		writeModules(webgl)
+		writeFile("Editor/Data/PlaybackEngines/WebGLSupport/ivy.xml", "WEBGL")
+
+		report, err := CheckEditorInstall(context.Background(), installer, "2019.4.9f1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Problems).To(ConsistOf(
+			FileProblem{Package: "webgl", Path: "Editor/Data/PlaybackEngines/WebGLSupport/ivy.xml", Problem: FileModified},
+		))
+	})
+
+	It("should not look for extra files if a package could not be checked", func() {
+		android := release.ModuleRelease{ID: "android", Selected: true}
+		android.DownloadURL = "https://example.com/android.pkg"
+		writeModules(android)
+		writeFile("Editor/Data/PlaybackEngines/AndroidPlayer/Tools/gradle", "gradle")
+
+		report, err := CheckEditorInstall(context.Background(), installer, "2019.4.9f1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Problems).To(BeEmpty())
+		Expect(report.Unverified).To(Equal([]string{"android"}))
+	})
+})
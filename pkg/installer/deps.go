@@ -0,0 +1,66 @@
+package installer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/wellplayedgames/unity-installer/pkg/release"
+)
+
+// ResolveModules returns the modules with the given IDs along with every
+// module they depend on, ordered so that dependencies come before the
+// modules which need them. Otherwise the order of moduleIDs is kept.
+func ResolveModules(editorRelease *release.EditorRelease, moduleIDs []string) ([]*release.ModuleRelease, error) {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	state := map[string]int{}
+	var ordered []*release.ModuleRelease
+	var path []string
+
+	var visit func(id, requiredBy string) error
+	visit = func(id, requiredBy string) error {
+		switch state[id] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("module dependency cycle: %s -> %s", strings.Join(path, " -> "), id)
+		}
+
+		m := editorRelease.FindModule(id)
+		if m == nil {
+			if requiredBy != "" {
+				target := editorRelease.Version
+				if editorRelease.Architecture != "" && editorRelease.Architecture != release.ArchX86_64 {
+					target = fmt.Sprintf("%s (%s)", target, editorRelease.Architecture)
+				}
+				return fmt.Errorf("module %s requires %s, which is not available for %s", requiredBy, id, target)
+			}
+			return fmt.Errorf("Missing module %s", id)
+		}
+
+		state[id] = visiting
+		path = append(path, id)
+
+		for _, dep := range m.Requires() {
+			if err := visit(dep, id); err != nil {
+				return err
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[id] = visited
+		ordered = append(ordered, m)
+		return nil
+	}
+
+	for _, id := range moduleIDs {
+		if err := visit(id, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+}
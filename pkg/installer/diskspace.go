@@ -0,0 +1,153 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/wellplayedgames/unity-installer/pkg/progress"
+	"github.com/wellplayedgames/unity-installer/pkg/release"
+)
+
+// DiskSpaceChecker is implemented by UnityInstallers which can check that
+// there is enough disk space to download and install packages.
+type DiskSpaceChecker interface {
+	CheckDiskSpace(pkgs []*release.Package) error
+}
+
+// VolumeSpace is the space needed and available on one volume.
+type VolumeSpace struct {
+	Paths     []string
+	Required  int64
+	Available int64
+}
+
+// InsufficientSpaceError is returned when there is not enough disk space to
+// install the requested packages.
+type InsufficientSpaceError struct {
+	Volumes []VolumeSpace
+}
+
+func (e *InsufficientSpaceError) Error() string {
+	var sb strings.Builder
+	sb.WriteString("not enough disk space:")
+
+	for _, v := range e.Volumes {
+		fmt.Fprintf(&sb, "\n  %s: %s required, %s available",
+			strings.Join(v.Paths, ", "), progress.FormatBytes(v.Required), progress.FormatBytes(v.Available))
+	}
+
+	return sb.String()
+}
+
+// existingParent returns the closest directory to path which exists.
+func existingParent(path string) string {
+	path, _ = filepath.Abs(path)
+
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// diskSpaceNeed is space needed in a directory.
+type diskSpaceNeed struct {
+	path  string
+	bytes int64
+}
+
+// checkDiskSpace checks that every volume has enough free space for the needs
+// which fall on it.
+func checkDiskSpace(needs []diskSpaceNeed) error {
+	volumes := map[string]*VolumeSpace{}
+	var order []string
+
+	for _, need := range needs {
+		if need.bytes <= 0 {
+			continue
+		}
+
+		path := existingParent(need.path)
+		free, volumeID, err := diskFree(path)
+		if err != nil {
+			return fmt.Errorf("failed to check free space in %s: %w", path, err)
+		}
+
+		v, ok := volumes[volumeID]
+		if !ok {
+			v = &VolumeSpace{Available: int64(free)}
+			volumes[volumeID] = v
+			order = append(order, volumeID)
+		}
+
+		v.Required += need.bytes
+		if len(v.Paths) == 0 || v.Paths[len(v.Paths)-1] != need.path {
+			v.Paths = append(v.Paths, need.path)
+		}
+	}
+
+	var insufficient []VolumeSpace
+	for _, id := range order {
+		if v := volumes[id]; v.Required > v.Available {
+			insufficient = append(insufficient, *v)
+		}
+	}
+
+	if len(insufficient) > 0 {
+		sort.Slice(insufficient, func(i, j int) bool {
+			return insufficient[i].Paths[0] < insufficient[j].Paths[0]
+		})
+		return &InsufficientSpaceError{insufficient}
+	}
+
+	return nil
+}
+
+// downloadSpace returns the space needed to download a package, estimated
+// from its installed size if the download size is unknown.
+func downloadSpace(pkg *release.Package) int64 {
+	if pkg.DownloadSize > 0 {
+		return int64(pkg.DownloadSize)
+	}
+
+	return int64(pkg.InstalledSize)
+}
+
+// downloadNeed returns the space needed to download a package, which is none
+// if it is local or already cached.
+func (d *Downloader) downloadNeed(pkg *release.Package) diskSpaceNeed {
+	if _, ok := release.FileURLPath(pkg.DownloadURL); ok {
+		return diskSpaceNeed{}
+	}
+
+	if d.Cache != nil {
+		if _, ok := d.Cache.Lookup(pkg); ok {
+			return diskSpaceNeed{}
+		}
+
+		return diskSpaceNeed{d.Cache.dir, downloadSpace(pkg)}
+	}
+
+	return diskSpaceNeed{d.TempDir, downloadSpace(pkg)}
+}
+
+// CheckDiskSpace implements the DiskSpaceChecker interface.
+func (i *simpleInstaller) CheckDiskSpace(pkgs []*release.Package) error {
+	var needs []diskSpaceNeed
+
+	for _, pkg := range pkgs {
+		needs = append(needs, i.downloader.downloadNeed(pkg))
+		needs = append(needs, diskSpaceNeed{i.editorDir, int64(pkg.InstalledSize)})
+	}
+
+	return checkDiskSpace(needs)
+}
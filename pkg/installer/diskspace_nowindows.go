@@ -0,0 +1,27 @@
+// +build !windows
+
+package installer
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// diskFree returns the free space available to this user on the volume
+// holding path, and an identifier for that volume.
+func diskFree(path string) (uint64, string, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, "", err
+	}
+
+	volumeID := path
+	if info, err := os.Stat(path); err == nil {
+		if sys, ok := info.Sys().(*syscall.Stat_t); ok {
+			volumeID = fmt.Sprint(sys.Dev)
+		}
+	}
+
+	return uint64(st.Bavail) * uint64(st.Bsize), volumeID, nil
+}
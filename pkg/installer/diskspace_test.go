@@ -0,0 +1,55 @@
+package installer
+
+import (
+	"errors"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/wellplayedgames/unity-installer/pkg/release"
+)
+
+var _ = Describe("CheckDiskSpace", func() {
+	var (
+		tempDir   string
+		installer *simpleInstaller
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "diskspace-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		downloader := NewDownloader(nil, nil, filepath.Join(tempDir, "downloads"))
+		installer = &simpleInstaller{downloader: downloader, editorDir: filepath.Join(tempDir, "editors")}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tempDir)).To(Succeed())
+	})
+
+	It("should accept packages which fit", func() {
+		pkg := &release.Package{DownloadSize: 1024, InstalledSize: 4096}
+		Expect(installer.CheckDiskSpace([]*release.Package{pkg})).To(Succeed())
+	})
+
+	It("should report packages which do not fit", func() {
+		pkg := &release.Package{DownloadSize: math.MaxInt64 / 4, InstalledSize: math.MaxInt64 / 4}
+		err := installer.CheckDiskSpace([]*release.Package{pkg})
+
+		var spaceErr *InsufficientSpaceError
+		Expect(errors.As(err, &spaceErr)).To(BeTrue())
+		Expect(spaceErr.Volumes).To(HaveLen(1))
+		Expect(spaceErr.Volumes[0].Paths).To(ConsistOf(installer.downloader.TempDir, installer.editorDir))
+		Expect(spaceErr.Volumes[0].Required).To(Equal(int64(math.MaxInt64 / 4 * 2)))
+		Expect(err.Error()).To(ContainSubstring("required"))
+	})
+
+	It("should not count local packages", func() {
+		pkg := &release.Package{DownloadURL: "file:///bundle/Unity.pkg", DownloadSize: math.MaxInt64 / 4}
+		Expect(installer.CheckDiskSpace([]*release.Package{pkg})).To(Succeed())
+	})
+})
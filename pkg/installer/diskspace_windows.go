@@ -0,0 +1,32 @@
+// +build windows
+
+package installer
+
+import (
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	dllKernel32            = syscall.NewLazyDLL("kernel32.dll")
+	procGetDiskFreeSpaceEx = dllKernel32.NewProc("GetDiskFreeSpaceExW")
+)
+
+// diskFree returns the free space available to this user on the volume
+// holding path, and an identifier for that volume.
+func diskFree(path string) (uint64, string, error) {
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, "", err
+	}
+
+	var freeBytes uint64
+	r, _, err := procGetDiskFreeSpaceEx.Call(uintptr(unsafe.Pointer(pathPtr)), uintptr(unsafe.Pointer(&freeBytes)), 0, 0)
+	if r == 0 {
+		return 0, "", err
+	}
+
+	return freeBytes, strings.ToLower(filepath.VolumeName(path)), nil
+}
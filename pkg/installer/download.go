@@ -0,0 +1,331 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/wellplayedgames/unity-installer/pkg/progress"
+	"github.com/wellplayedgames/unity-installer/pkg/release"
+	"github.com/wellplayedgames/unity-installer/pkg/retry"
+)
+
+const (
+	partSuffix        = ".part"
+	validatorSuffix   = ".validator"
+	maxResumeAttempts = 10
+)
+
+// Downloader fetches packages to local disk, optionally via a persistent
+// download cache.
+type Downloader struct {
+	Logger     logr.Logger
+	HTTPClient *http.Client
+	TempDir    string
+	Cache      *DownloadCache
+	Retry      retry.Policy
+
+	// Observer is optional and receives download progress.
+	Observer progress.Observer
+
+	// Rewrites redirect download URLs, for example to a mirror. If
+	// RewriteFallback is set, the original URL is tried if the rewritten one
+	// fails.
+	Rewrites        []RewriteRule
+	RewriteFallback bool
+}
+
+// NewDownloader creates a Downloader which downloads packages into tempDir.
+// Partial downloads are resumed from tempDir, or from the cache if one is
+// set, so only a persistent directory lets a later run resume them.
+func NewDownloader(logger logr.Logger, client *http.Client, tempDir string) *Downloader {
+	return &Downloader{
+		Logger:     logger,
+		HTTPClient: client,
+		TempDir:    tempDir,
+		Retry:      retry.DefaultPolicy,
+	}
+}
+
+// Download fetches a package and returns the local path to it. If a cache is
+// configured, the package is served from or stored in the cache.
+func (d *Downloader) Download(ctx context.Context, pkg *release.Package) (string, error) {
+	if localPath, ok := release.FileURLPath(pkg.DownloadURL); ok {
+		// Local packages, such as those in a bundle, are used in place.
+		verifier := newPackageVerifier(pkg)
+		if err := copyFileTo(localPath, verifier); err != nil {
+			return "", err
+		}
+
+		return localPath, verifier.Verify()
+	}
+
+	if d.Cache != nil {
+		return d.Cache.Fetch(ctx, pkg, func(targetPath string) error {
+			return d.DownloadTo(ctx, pkg, targetPath)
+		})
+	}
+
+	_, fileName := path.Split(pkg.DownloadURL)
+	targetPath := filepath.Join(d.TempDir, fileName)
+
+	if err := d.DownloadTo(ctx, pkg, targetPath); err != nil {
+		return "", err
+	}
+
+	return targetPath, nil
+}
+
+// DownloadTo fetches a package to a specific path, verifying it against the
+// package spec. Interrupted downloads are kept alongside targetPath and
+// resumed where the server supports it, but nothing is left at targetPath
+// itself on failure.
+func (d *Downloader) DownloadTo(ctx context.Context, pkg *release.Package, targetPath string) error {
+	mirrorURL, ok := RewriteURL(d.Rewrites, pkg.DownloadURL)
+	if !ok {
+		return d.downloadTo(ctx, pkg, targetPath)
+	}
+
+	mirrored := *pkg
+	mirrored.DownloadURL = mirrorURL
+
+	err := d.downloadTo(ctx, &mirrored, targetPath)
+	if err == nil || !d.RewriteFallback || ctx.Err() != nil {
+		return err
+	}
+
+	d.Logger.Error(err, "failed to download from rewritten URL, falling back", "url", mirrorURL, "package", pkg.DownloadURL)
+	d.removePart(targetPath + partSuffix)
+	return d.downloadTo(ctx, pkg, targetPath)
+}
+
+func (d *Downloader) downloadTo(ctx context.Context, pkg *release.Package, targetPath string) error {
+	d.Logger.Info("downloading package", "package", pkg.DownloadURL)
+	partPath := targetPath + partSuffix
+
+	if localPath, ok := release.FileURLPath(pkg.DownloadURL); ok {
+		return d.copyLocal(pkg, localPath, targetPath)
+	}
+	tracker := progress.Start(d.Observer, pkg.DownloadURL, progress.PhaseDownload, int64(pkg.DownloadSize))
+
+	err := d.Retry.Do(ctx, func() error {
+		for resumes := 0; ; resumes++ {
+			progressed, err := d.downloadPart(ctx, pkg, partPath, tracker)
+			if err == nil {
+				return nil
+			}
+
+			// Resume straight away whilst we're making progress, otherwise
+			// leave it to the retry policy.
+			var mismatch *ChecksumMismatchError
+			if errors.As(err, &mismatch) || !progressed || resumes >= maxResumeAttempts || ctx.Err() != nil {
+				return err
+			}
+
+			d.Logger.Info("download interrupted, resuming", "package", pkg.DownloadURL, "error", err.Error())
+		}
+	})
+	if err == nil {
+		err = os.Rename(partPath, targetPath)
+	}
+
+	tracker.Finish(err)
+	return err
+}
+
+// downloadPart downloads a package into partPath, resuming any previous
+// partial download. Returns whether any new data was received.
+func (d *Downloader) downloadPart(ctx context.Context, pkg *release.Package, partPath string, tracker *progress.Tracker) (bool, error) {
+	validatorPath := partPath + validatorSuffix
+	offset := int64(0)
+	validator := ""
+
+	if info, err := os.Stat(partPath); err == nil {
+		if b, err := ioutil.ReadFile(validatorPath); err == nil && len(b) > 0 {
+			offset = info.Size()
+			validator = string(b)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pkg.DownloadURL, nil)
+	if err != nil {
+		return false, err
+	}
+
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", validator)
+	}
+
+	resp, err := d.HTTPClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			d.Logger.Error(err, "failed to close download body")
+		}
+	}()
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0 && contentRangeStart(resp) == offset:
+		d.Logger.Info("resuming download", "package", pkg.DownloadURL, "offset", offset)
+		flags = os.O_WRONLY | os.O_APPEND
+
+	case resp.StatusCode == http.StatusOK:
+		// Either a fresh download or the server ignored the range.
+		offset = 0
+
+	case offset > 0 && (resp.StatusCode == http.StatusPartialContent || resp.StatusCode == http.StatusRequestedRangeNotSatisfiable):
+		// The partial download can't be resumed, start again.
+		d.removePart(partPath)
+		return d.downloadPart(ctx, pkg, partPath, tracker)
+
+	default:
+		return false, retry.NewStatusError(resp)
+	}
+
+	if v := responseValidator(resp); v != "" {
+		err = ioutil.WriteFile(validatorPath, []byte(v), 0666)
+	} else {
+		err = os.Remove(validatorPath)
+		if os.IsNotExist(err) {
+			err = nil
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+
+	tracker.SetDone(offset)
+	if pkg.DownloadSize <= 0 && resp.ContentLength > 0 {
+		tracker.SetTotal(offset + resp.ContentLength)
+	}
+
+	verifier := newPackageVerifier(pkg)
+	if offset > 0 {
+		if err := copyFileTo(partPath, verifier); err != nil {
+			return false, err
+		}
+	}
+
+	target, err := os.OpenFile(partPath, flags, os.ModePerm)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := io.Copy(io.MultiWriter(target, verifier, tracker), resp.Body)
+	if cerr := target.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		// Keep what we have so that it can be resumed.
+		return n > 0, err
+	}
+
+	if err := verifier.Verify(); err != nil {
+		d.removePart(partPath)
+		return true, err
+	}
+
+	if err := os.Remove(validatorPath); err != nil && !os.IsNotExist(err) {
+		d.Logger.Error(err, "failed to remove download validator", "path", validatorPath)
+	}
+
+	return true, nil
+}
+
+func (d *Downloader) copyLocal(pkg *release.Package, localPath, targetPath string) error {
+	partPath := targetPath + partSuffix
+
+	src, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	target, err := os.OpenFile(partPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	verifier := newPackageVerifier(pkg)
+	_, err = io.Copy(io.MultiWriter(target, verifier), src)
+	if cerr := target.Close(); err == nil {
+		err = cerr
+	}
+
+	if err == nil {
+		err = verifier.Verify()
+	}
+
+	if err != nil {
+		d.removePart(partPath)
+		return err
+	}
+
+	return os.Rename(partPath, targetPath)
+}
+
+func (d *Downloader) removePart(partPath string) {
+	for _, p := range []string{partPath, partPath + validatorSuffix} {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			d.Logger.Error(err, "failed to remove partial download", "path", p)
+		}
+	}
+}
+
+// responseValidator returns a value suitable for If-Range, preferring a strong
+// ETag over Last-Modified.
+func responseValidator(resp *http.Response) string {
+	if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+
+	return resp.Header.Get("Last-Modified")
+}
+
+// contentRangeStart returns the first byte offset of a Content-Range header,
+// or -1 if it is missing or malformed.
+func contentRangeStart(resp *http.Response) int64 {
+	var start, end, size int64
+	cr := resp.Header.Get("Content-Range")
+	if _, err := fmt.Sscanf(cr, "bytes %d-%d/%d", &start, &end, &size); err != nil {
+		if _, err := fmt.Sscanf(cr, "bytes %d-%d/*", &start, &end); err != nil {
+			return -1
+		}
+	}
+
+	return start
+}
+
+// FileURL returns a file:// URL for a local path.
+func FileURL(localPath string) string {
+	p := filepath.ToSlash(localPath)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+func copyFileTo(path string, w io.Writer) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,257 @@
+package installer
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/wellplayedgames/unity-installer/pkg/release"
+)
+
+// cuttingWriter aborts the connection after a number of bytes have been
+// written.
+type cuttingWriter struct {
+	http.ResponseWriter
+	remaining int
+}
+
+func (w *cuttingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.remaining {
+		_, _ = w.ResponseWriter.Write(p[:w.remaining])
+		w.ResponseWriter.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}
+
+	w.remaining -= len(p)
+	return w.ResponseWriter.Write(p)
+}
+
+type testPackageServer struct {
+	*httptest.Server
+	content       []byte
+	supportRanges bool
+	cutAfter      int
+
+	lock     sync.Mutex
+	requests int
+	ranges   []string
+}
+
+func newTestPackageServer(content []byte, supportRanges bool, cutAfter int) *testPackageServer {
+	s := &testPackageServer{content: content, supportRanges: supportRanges, cutAfter: cutAfter}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func (s *testPackageServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	s.requests++
+	first := s.requests == 1
+	s.ranges = append(s.ranges, r.Header.Get("Range"))
+	s.lock.Unlock()
+
+	if first && s.cutAfter > 0 {
+		w = &cuttingWriter{w, s.cutAfter}
+	}
+
+	if !s.supportRanges {
+		r.Header.Del("Range")
+		w.Header().Set("Accept-Ranges", "none")
+	}
+
+	w.Header().Set("ETag", `"test-etag"`)
+	http.ServeContent(w, r, "Unity.zip", time.Unix(0, 0), bytes.NewReader(s.content))
+}
+
+var _ = Describe("Downloader", func() {
+	var (
+		tempDir    string
+		downloader *Downloader
+		content    []byte
+		pkg        *release.Package
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "downloader-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		content = bytes.Repeat([]byte("0123456789abcdef"), 64*1024)
+		sum := md5.Sum(content)
+		pkg = &release.Package{DownloadSize: release.Size(len(content))}
+		pkg.Checksum = hex.EncodeToString(sum[:])
+
+		downloader = NewDownloader(logrtesting.NullLogger{}, http.DefaultClient, tempDir)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tempDir)).To(Succeed())
+	})
+
+	It("should resume a download after the connection is cut", func() {
+		server := newTestPackageServer(content, true, len(content)/2)
+		defer server.Close()
+		pkg.DownloadURL = server.URL + "/Unity.zip"
+
+		path, err := downloader.Download(context.Background(), pkg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ioutil.ReadFile(path)).To(Equal(content))
+		Expect(server.ranges).To(HaveLen(2))
+		Expect(server.ranges[1]).To(MatchRegexp(`^bytes=[1-9][0-9]*-$`))
+	})
+
+	It("should fall back to a full download when ranges are ignored", func() {
+		server := newTestPackageServer(content, false, len(content)/2)
+		defer server.Close()
+		pkg.DownloadURL = server.URL + "/Unity.zip"
+
+		path, err := downloader.Download(context.Background(), pkg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ioutil.ReadFile(path)).To(Equal(content))
+		Expect(server.requests).To(Equal(2))
+	})
+
+	It("should reject a download with the wrong checksum", func() {
+		server := newTestPackageServer(content, true, 0)
+		defer server.Close()
+		pkg.DownloadURL = server.URL + "/Unity.zip"
+		pkg.Checksum = "00000000000000000000000000000000"
+
+		_, err := downloader.Download(context.Background(), pkg)
+		var mismatch *ChecksumMismatchError
+		Expect(errors.As(err, &mismatch)).To(BeTrue())
+		Expect(mismatch.Algorithm).To(Equal("md5"))
+
+		entries, err := ioutil.ReadDir(tempDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(BeEmpty())
+	})
+
+	It("should verify subresource integrity hashes", func() {
+		server := newTestPackageServer(content, true, 0)
+		defer server.Close()
+		pkg.DownloadURL = server.URL + "/Unity.zip"
+
+		sum := sha512.Sum384(content)
+		pkg.Integrity = "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+		_, err := downloader.Download(context.Background(), pkg)
+		Expect(err).NotTo(HaveOccurred())
+
+		pkg.Integrity = "sha384-" + base64.StdEncoding.EncodeToString(make([]byte, sha512.Size384))
+		_, err = downloader.Download(context.Background(), pkg)
+		var mismatch *ChecksumMismatchError
+		Expect(errors.As(err, &mismatch)).To(BeTrue())
+		Expect(mismatch.Algorithm).To(Equal("sha384"))
+	})
+
+	It("should serve repeated downloads from the cache", func() {
+		server := newTestPackageServer(content, true, 0)
+		defer server.Close()
+		pkg.DownloadURL = server.URL + "/Unity.zip"
+
+		cache, err := NewDownloadCache(logrtesting.NullLogger{}, filepath.Join(tempDir, "cache"), 0)
+		Expect(err).NotTo(HaveOccurred())
+		downloader.Cache = cache
+
+		first, err := downloader.Download(context.Background(), pkg)
+		Expect(err).NotTo(HaveOccurred())
+		second, err := downloader.Download(context.Background(), pkg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(second).To(Equal(first))
+		Expect(server.requests).To(Equal(1))
+	})
+
+	It("should resume a download left in the cache by an earlier run", func() {
+		server := newTestPackageServer(content, true, 0)
+		defer server.Close()
+		pkg.DownloadURL = server.URL + "/Unity.zip"
+
+		cache, err := NewDownloadCache(logrtesting.NullLogger{}, filepath.Join(tempDir, "cache"), 0)
+		Expect(err).NotTo(HaveOccurred())
+		downloader.Cache = cache
+
+		// An earlier run was stopped half way through the download.
+		partPath := cache.entryPath(cacheKey(pkg), pkg) + partSuffix
+		Expect(os.MkdirAll(filepath.Dir(partPath), os.ModePerm)).To(Succeed())
+		Expect(ioutil.WriteFile(partPath, content[:len(content)/2], 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(partPath+validatorSuffix, []byte(`"test-etag"`), 0644)).To(Succeed())
+
+		path, err := downloader.Download(context.Background(), pkg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ioutil.ReadFile(path)).To(Equal(content))
+		Expect(server.ranges).To(Equal([]string{fmt.Sprintf("bytes=%d-", len(content)/2)}))
+		Expect(cache.Close()).To(Succeed())
+	})
+
+	Context("when installing", func() {
+		var (
+			server           *testPackageServer
+			packageInstaller *recordingPackageInstaller
+		)
+
+		install := func() error {
+			pkg.DownloadURL = server.URL + "/Unity.tar.xz"
+			editorRelease := &release.EditorRelease{Version: "2019.4.9f1", Package: *pkg}
+
+			unityInstaller, err := NewInstaller(logrtesting.NullLogger{}, filepath.Join(tempDir, "editors"), "", downloader)
+			Expect(err).NotTo(HaveOccurred())
+
+			return EnsureEditorWithModules(context.Background(), "linux", unityInstaller, packageInstaller, editorRelease, nil, false, false, 1)
+		}
+
+		expectMismatch := func(err error, algorithm string) {
+			var mismatch *ChecksumMismatchError
+			Expect(errors.As(err, &mismatch)).To(BeTrue(), fmt.Sprint(err))
+			Expect(mismatch.Algorithm).To(Equal(algorithm))
+			Expect(packageInstaller.options).To(BeEmpty())
+		}
+
+		BeforeEach(func() {
+			server = newTestPackageServer(content, true, 0)
+			packageInstaller = &recordingPackageInstaller{}
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should install packages which match their checksums", func() {
+			sum := sha256.Sum256(content)
+			pkg.SHA256 = hex.EncodeToString(sum[:])
+
+			Expect(install()).To(Succeed())
+			Expect(packageInstaller.options).To(HaveLen(1))
+		})
+
+		It("should not install a package with the wrong MD5", func() {
+			pkg.Checksum = "00000000000000000000000000000000"
+			expectMismatch(install(), "md5")
+		})
+
+		It("should not install a package with the wrong SHA-256", func() {
+			pkg.SHA256 = hex.EncodeToString(make([]byte, sha256.Size))
+			expectMismatch(install(), "sha256")
+		})
+
+		It("should not install a truncated package", func() {
+			server.content = content[:len(content)/2]
+			expectMismatch(install(), "size")
+		})
+	})
+})
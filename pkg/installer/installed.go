@@ -0,0 +1,181 @@
+package installer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	packageinstaller "github.com/wellplayedgames/unity-installer/pkg/package-installer"
+	"github.com/wellplayedgames/unity-installer/pkg/release"
+)
+
+var unityBuildNumberRegexp = regexp.MustCompile(`<key>UnityBuildNumber</key>\s*<string>([0-9a-fA-F]+)</string>`)
+
+// InstalledEditor is an editor found in an install directory.
+type InstalledEditor struct {
+	Version      string `json:"version"`
+	Revision     string `json:"revision,omitempty"`
+	Platform     string `json:"platform"`
+	Architecture string `json:"architecture,omitempty"`
+	Path         string `json:"path"`
+
+	// Size is the disk usage of the editor directory in bytes.
+	Size int64 `json:"size"`
+
+	// LastUsed is when the editor was last run or installed into, as far as
+	// the file system records access times.
+	LastUsed time.Time `json:"lastUsed"`
+
+	// Modules lists the IDs of the selected modules.
+	Modules []string `json:"modules"`
+
+	// HasModulesFile is false if the editor has no modules.json, such as
+	// editors which were copied into place or whose install was interrupted.
+	HasModulesFile bool `json:"hasModulesFile"`
+}
+
+func directorySize(dir string) (int64, error) {
+	var size int64
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+
+		return nil
+	})
+
+	return size, err
+}
+
+// editorBinary returns the path of the editor executable for a platform,
+// relative to the editor directory.
+func editorBinary(platform string) string {
+	switch platform {
+	case "win32":
+		return filepath.Join("Editor", "Unity.exe")
+	case "darwin":
+		return filepath.Join("Unity.app", "Contents", "MacOS", "Unity")
+	default:
+		return filepath.Join("Editor", "Unity")
+	}
+}
+
+// lastUsed returns the latest of when the editor executable was last
+// accessed and when its modules were last changed.
+func lastUsed(editorPath, platform string) time.Time {
+	var latest time.Time
+
+	if info, err := os.Stat(filepath.Join(editorPath, editorBinary(platform))); err == nil {
+		latest = accessTime(info)
+	}
+
+	if info, err := os.Stat(filepath.Join(editorPath, packageinstaller.ModulesFile)); err == nil && info.ModTime().After(latest) {
+		latest = info.ModTime()
+	}
+
+	return latest
+}
+
+// macEditorRevision reads the revision of a macOS editor from its bundle.
+func macEditorRevision(editorDir string) string {
+	b, err := ioutil.ReadFile(filepath.Join(editorDir, "Unity.app", "Contents", "Info.plist"))
+	if err != nil {
+		return ""
+	}
+
+	if match := unityBuildNumberRegexp.FindSubmatch(b); match != nil {
+		return strings.ToLower(string(match[1]))
+	}
+
+	return ""
+}
+
+// splitEditorDirName splits an editor directory name into a version and, for
+// directories created for other architectures, an architecture.
+func splitEditorDirName(name string) (string, string) {
+	if idx := strings.LastIndex(name, "-"); idx >= 0 {
+		if arch, err := release.NormalizeArchitecture(name[idx+1:]); err == nil {
+			return name[:idx], arch
+		}
+	}
+
+	return name, ""
+}
+
+// FindInstalledEditors returns the editors installed in dir, which holds a
+// directory per editor as created by this installer or Unity Hub. A missing
+// dir has no editors.
+func FindInstalledEditors(logger logr.Logger, dir string) ([]InstalledEditor, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	i := &simpleInstaller{logger: logger, editorDir: dir}
+	var editors []InstalledEditor
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		found, modules, err := i.CheckEditorVersion(entry.Name())
+		if !found {
+			continue
+		} else if err != nil {
+			logger.Error(err, "failed to read installed modules", "editor", entry.Name())
+		}
+
+		editorPath := filepath.Join(dir, entry.Name())
+		e := InstalledEditor{
+			Platform:       editorPlatform(editorPath),
+			Path:           editorPath,
+			Modules:        []string{},
+			HasModulesFile: checkFileExists(filepath.Join(editorPath, packageinstaller.ModulesFile)),
+		}
+		e.Version, e.Architecture = splitEditorDirName(entry.Name())
+
+		if manifest, err := packageinstaller.ReadManifest(editorPath, packageinstaller.EditorManifest); err == nil {
+			if manifest.Version != "" {
+				e.Version = manifest.Version
+			}
+			if manifest.Architecture != "" {
+				e.Architecture = manifest.Architecture
+			}
+			e.Revision = manifest.Revision
+		}
+
+		if e.Revision == "" && e.Platform == "darwin" {
+			e.Revision = macEditorRevision(editorPath)
+		}
+
+		for _, m := range modules {
+			if m.Selected {
+				e.Modules = append(e.Modules, m.ID)
+			}
+		}
+		sort.Strings(e.Modules)
+
+		e.LastUsed = lastUsed(editorPath, e.Platform)
+
+		if e.Size, err = directorySize(editorPath); err != nil {
+			logger.Error(err, "failed to measure editor size", "editor", editorPath)
+		}
+
+		editors = append(editors, e)
+	}
+
+	return editors, nil
+}
@@ -0,0 +1,67 @@
+package installer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	packageinstaller "github.com/wellplayedgames/unity-installer/pkg/package-installer"
+)
+
+var _ = Describe("FindInstalledEditors", func() {
+	var tempDir string
+
+	writeFile := func(relPath, content string) {
+		p := filepath.Join(tempDir, filepath.FromSlash(relPath))
+		Expect(os.MkdirAll(filepath.Dir(p), os.ModePerm)).To(Succeed())
+		Expect(ioutil.WriteFile(p, []byte(content), 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "installed-test")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tempDir)).To(Succeed())
+	})
+
+	It("should describe installed editors", func() {
+		writeFile("2019.4.9f1/Editor/Unity.exe", "unity")
+		writeFile("2019.4.9f1/modules.json", `[{"id": "webgl", "selected": true}, {"id": "ios", "selected": false}]`)
+
+		manifest, err := json.Marshal(&packageinstaller.Manifest{Name: "editor", Version: "2019.4.9f1", Revision: "50fe8a171dd9"})
+		Expect(err).NotTo(HaveOccurred())
+		writeFile("2019.4.9f1/manifests/editor.json", string(manifest))
+
+		writeFile("2021.3.5f1-arm64/Unity.app/Contents/Info.plist", "<key>UnityBuildNumber</key>\n<string>40eb3a945986</string>")
+		writeFile("Hub/secondaryInstallPath.json", "")
+
+		editors, err := FindInstalledEditors(nil, tempDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(editors).To(HaveLen(2))
+
+		Expect(editors[0].Version).To(Equal("2019.4.9f1"))
+		Expect(editors[0].Revision).To(Equal("50fe8a171dd9"))
+		Expect(editors[0].Platform).To(Equal("win32"))
+		Expect(editors[0].Modules).To(Equal([]string{"webgl"}))
+		Expect(editors[0].HasModulesFile).To(BeTrue())
+		Expect(editors[0].Size).To(BeNumerically(">=", 5))
+
+		Expect(editors[1].Version).To(Equal("2021.3.5f1"))
+		Expect(editors[1].Architecture).To(Equal("arm64"))
+		Expect(editors[1].Revision).To(Equal("40eb3a945986"))
+		Expect(editors[1].Platform).To(Equal("darwin"))
+		Expect(editors[1].HasModulesFile).To(BeFalse())
+	})
+
+	It("should find nothing in a missing directory", func() {
+		editors, err := FindInstalledEditors(nil, filepath.Join(tempDir, "missing"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(editors).To(BeEmpty())
+	})
+})
@@ -1,13 +1,13 @@
 package installer
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/go-logr/logr"
 	"io"
 	"net/http"
 	"os"
-	"path"
 	"path/filepath"
 
 	packageinstaller "github.com/wellplayedgames/unity-installer/pkg/package-installer"
@@ -18,83 +18,110 @@ import (
 type UnityInstaller interface {
 	io.Closer
 
-	InstallEditor(platform string, installer packageinstaller.PackageInstaller, spec *release.EditorRelease) error
-	InstallModule(installer packageinstaller.PackageInstaller, editorVersion string, spec *release.ModuleRelease) error
+	DownloadPackage(ctx context.Context, pkg *release.Package) (string, error)
+	InstallEditor(platform string, installer packageinstaller.PackageInstaller, spec *release.EditorRelease, packagePath string) error
+	InstallModule(installer packageinstaller.PackageInstaller, editorVersion string, spec *release.ModuleRelease, packagePath string) error
 
 	CheckEditorVersion(editorVersion string) (bool, []release.ModuleRelease, error)
+	EditorPath(editorVersion string) string
+
+	UninstallEditor(installer packageinstaller.PackageInstaller, editorVersion string) error
+	UninstallModules(installer packageinstaller.PackageInstaller, editorVersion string, moduleIDs []string) ([]string, error)
 }
 
 type simpleInstaller struct {
 	logger     logr.Logger
-	httpClient *http.Client
+	downloader *Downloader
 	editorDir  string
-	tempDir    string
+	arch       string
 }
 
 // NewSimpleInstaller creates a Unity Installer which downloads packages to a
 // temporary directory every install.
 func NewSimpleInstaller(logger logr.Logger, editorDir, tempDir string, client *http.Client) (UnityInstaller, error) {
-	i := &simpleInstaller{logger, client, editorDir, tempDir}
-	return i, nil
+	return NewInstaller(logger, editorDir, "", NewDownloader(logger, client, tempDir))
 }
 
-func (i *simpleInstaller) Close() error {
-	return nil
+// NewInstaller creates a Unity Installer which fetches packages using the
+// given downloader. Editors for arch are installed into <version>, or
+// <version>-<arch> if arch is not the host architecture.
+func NewInstaller(logger logr.Logger, editorDir, arch string, downloader *Downloader) (UnityInstaller, error) {
+	i := &simpleInstaller{logger, downloader, editorDir, arch}
+	return i, nil
 }
 
-func (i *simpleInstaller) downloadPackage(pkg *release.Package) (string, error) {
-	i.logger.Info("downloading package", "package", pkg.DownloadURL)
+// EditorPath returns the directory an editor version is installed in.
+func (i *simpleInstaller) EditorPath(editorVersion string) string {
+	if i.arch == "" || i.arch == release.HostArchitecture() {
+		return filepath.Join(i.editorDir, editorVersion)
+	}
+
+	return filepath.Join(i.editorDir, fmt.Sprintf("%s-%s", editorVersion, i.arch))
+}
 
-	_, fileName := path.Split(pkg.DownloadURL)
-	targetPath := filepath.Join(i.tempDir, fileName)
+// ArchitectureChecker is implemented by UnityInstallers which only install
+// editors for one architecture.
+type ArchitectureChecker interface {
+	CheckArchitecture(spec *release.EditorRelease) error
+}
 
-	resp, err := i.httpClient.Get(pkg.DownloadURL)
-	if err != nil {
-		return "", err
+// CheckArchitecture implements the ArchitectureChecker interface. Releases
+// without an architecture are for x86_64.
+func (i *simpleInstaller) CheckArchitecture(spec *release.EditorRelease) error {
+	arch := spec.Architecture
+	if arch == "" {
+		arch = release.ArchX86_64
 	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			i.logger.Error(err, "failed to close download body")
-		}
-	}()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("error fetching package: %d", resp.StatusCode)
+	if i.arch != "" && arch != i.arch {
+		return fmt.Errorf("Unity %s is for %s but editors are being installed for %s: use --arch=%s to install it", spec.Version, arch, i.arch, arch)
 	}
 
-	target, err := os.OpenFile(targetPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.ModePerm)
-	if err != nil {
-		return "", err
+	return nil
+}
+
+func (i *simpleInstaller) Close() error {
+	if i.downloader != nil && i.downloader.Cache != nil {
+		return i.downloader.Cache.Close()
 	}
-	defer func() {
-		if err := target.Close(); err != nil {
-			i.logger.Error(err, "failed to close target file")
-		}
-	}()
 
-	_, err = io.Copy(target, resp.Body)
-	return targetPath, err
+	return nil
+}
+
+func (i *simpleInstaller) DownloadPackage(ctx context.Context, pkg *release.Package) (string, error) {
+	return i.downloader.Download(ctx, pkg)
 }
 
-func (i *simpleInstaller) InstallEditor(platform string, packageInstaller packageinstaller.PackageInstaller, spec *release.EditorRelease) error {
-	targetPath := filepath.Join(i.editorDir, spec.Version)
+func (i *simpleInstaller) InstallEditor(platform string, packageInstaller packageinstaller.PackageInstaller, spec *release.EditorRelease, packagePath string) error {
+	if err := i.CheckArchitecture(spec); err != nil {
+		return err
+	}
 
-	packagePath, err := i.downloadPackage(&spec.Package)
-	if err == nil {
-		installOptions := release.InstallOptions{
-			Destination: &targetPath,
-		}
+	targetPath := i.EditorPath(spec.Version)
 
-		if platform == "darwin" {
-			renameFrom := "{UNITY_PATH}/Unity"
-			renameTo := "{UNITY_PATH}"
-			installOptions.RenameFrom = &renameFrom
-			installOptions.RenameTo = &renameTo
-		}
+	installOptions := release.InstallOptions{
+		Destination: &targetPath,
+	}
+
+	// macOS packages hold the editor in a Unity directory which is moved into
+	// place. Windows installers and Linux tarballs install straight into the
+	// target.
+	if platform == "darwin" {
+		renameFrom := "{UNITY_PATH}/Unity"
+		renameTo := "{UNITY_PATH}"
+		installOptions.RenameFrom = &renameFrom
+		installOptions.RenameTo = &renameTo
+	}
 
-		err = packageInstaller.InstallPackage(packagePath, targetPath, installOptions)
+	manifest := &packageinstaller.Manifest{
+		Name:         packageinstaller.EditorManifest,
+		Version:      spec.Version,
+		Revision:     spec.Revision,
+		Architecture: spec.Architecture,
 	}
 
+	err := packageInstaller.InstallPackage(packagePath, targetPath, installOptions, manifest)
+
 	if err == nil {
 		mods := make([]release.ModuleRelease, len(spec.Modules))
 
@@ -109,18 +136,14 @@ func (i *simpleInstaller) InstallEditor(platform string, packageInstaller packag
 	return err
 }
 
-func (i *simpleInstaller) InstallModule(packageInstaller packageinstaller.PackageInstaller, editorVersion string, spec *release.ModuleRelease) error {
+func (i *simpleInstaller) InstallModule(packageInstaller packageinstaller.PackageInstaller, editorVersion string, spec *release.ModuleRelease, packagePath string) error {
 	_, existingModules, err := i.CheckEditorVersion(editorVersion)
 	if err != nil {
 		return err
 	}
 
-	targetPath := filepath.Join(i.editorDir, editorVersion)
-
-	packagePath, err := i.downloadPackage(&spec.Package)
-	if err == nil {
-		err = packageInstaller.InstallPackage(packagePath, targetPath, spec.InstallOptions)
-	}
+	targetPath := i.EditorPath(editorVersion)
+	err = packageInstaller.InstallPackage(packagePath, targetPath, spec.InstallOptions, &packageinstaller.Manifest{Name: spec.ID})
 
 	// Update modules
 	if err == nil {
@@ -154,22 +177,35 @@ func checkFileExists(path string) bool {
 	return false
 }
 
-func checkEditorDirectory(editorDir string) bool {
-	winPath := filepath.Join(editorDir, "Editor", "Unity.exe")
-	if checkFileExists(winPath) {
-		return true
-	}
+// editorExecutables are the paths, relative to an editor directory, which
+// show that an editor for a platform is installed there.
+var editorExecutables = []struct {
+	path     string
+	platform string
+}{
+	{filepath.Join("Editor", "Unity.exe"), "win32"},
+	{"Unity.app", "darwin"},
+	{filepath.Join("Editor", "Unity"), "linux"},
+}
 
-	macPath := filepath.Join(editorDir, "Unity.app")
-	if checkFileExists(macPath) {
-		return true
+// editorPlatform returns the platform of the editor installed in editorDir,
+// or "" if there is no editor there.
+func editorPlatform(editorDir string) string {
+	for _, executable := range editorExecutables {
+		if checkFileExists(filepath.Join(editorDir, executable.path)) {
+			return executable.platform
+		}
 	}
 
-	return false
+	return ""
+}
+
+func checkEditorDirectory(editorDir string) bool {
+	return editorPlatform(editorDir) != ""
 }
 
 func (i *simpleInstaller) CheckEditorVersion(editorVersion string) (bool, []release.ModuleRelease, error) {
-	editorDir := filepath.Join(i.editorDir, editorVersion)
+	editorDir := i.EditorPath(editorVersion)
 	if !checkEditorDirectory(editorDir) {
 		return false, nil, nil
 	}
@@ -0,0 +1,110 @@
+package installer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	packageinstaller "github.com/wellplayedgames/unity-installer/pkg/package-installer"
+	"github.com/wellplayedgames/unity-installer/pkg/release"
+)
+
+type recordingPackageInstaller struct {
+	options []release.InstallOptions
+	modules []release.ModuleRelease
+	removed []string
+}
+
+func (r *recordingPackageInstaller) Close() error {
+	return nil
+}
+
+func (r *recordingPackageInstaller) InstallPackage(packagePath string, destination string, options release.InstallOptions, manifest *packageinstaller.Manifest) error {
+	r.options = append(r.options, options)
+	return nil
+}
+
+func (r *recordingPackageInstaller) StoreModules(destination string, modules []release.ModuleRelease) error {
+	r.modules = modules
+	return nil
+}
+
+func (r *recordingPackageInstaller) RemovePath(path string) error {
+	r.removed = append(r.removed, path)
+	return os.RemoveAll(path)
+}
+
+var _ = Describe("simpleInstaller", func() {
+	var (
+		tempDir   string
+		installer *simpleInstaller
+	)
+
+	writeFile := func(path, content string) {
+		Expect(os.MkdirAll(filepath.Dir(path), os.ModePerm)).To(Succeed())
+		Expect(ioutil.WriteFile(path, []byte(content), 0755)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "installer-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		installer = &simpleInstaller{editorDir: tempDir}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tempDir)).To(Succeed())
+	})
+
+	It("should detect Linux editors", func() {
+		editorDir := filepath.Join(tempDir, "2019.4.9f1")
+		writeFile(filepath.Join(editorDir, "Editor", "Unity"), "#!/bin/sh\n")
+
+		modules, err := json.Marshal([]release.ModuleRelease{{ID: "android", Selected: true}})
+		Expect(err).NotTo(HaveOccurred())
+		writeFile(filepath.Join(editorDir, packageinstaller.ModulesFile), string(modules))
+
+		found, installed, err := installer.CheckEditorVersion("2019.4.9f1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(installed).To(HaveLen(1))
+		Expect(installed[0].ID).To(Equal("android"))
+	})
+
+	It("should not detect a directory without an editor", func() {
+		writeFile(filepath.Join(tempDir, "2019.4.9f1", "Editor", "Data", "Resources", "unity default resources"), "")
+
+		found, _, err := installer.CheckEditorVersion("2019.4.9f1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeFalse())
+	})
+
+	It("should extract Linux editors straight into the editor directory", func() {
+		packageInstaller := &recordingPackageInstaller{}
+		spec := &release.EditorRelease{Version: "2019.4.9f1"}
+
+		Expect(installer.InstallEditor("linux", packageInstaller, spec, "Unity.tar.xz")).To(Succeed())
+		Expect(packageInstaller.options).To(HaveLen(1))
+		Expect(*packageInstaller.options[0].Destination).To(Equal(filepath.Join(tempDir, "2019.4.9f1")))
+		Expect(packageInstaller.options[0].RenameFrom).To(BeNil())
+
+		Expect(installer.InstallEditor("darwin", packageInstaller, spec, "Unity.pkg")).To(Succeed())
+		Expect(*packageInstaller.options[1].RenameFrom).To(Equal("{UNITY_PATH}/Unity"))
+	})
+
+	It("should refuse releases for another architecture", func() {
+		installer.arch = release.ArchARM64
+		packageInstaller := &recordingPackageInstaller{}
+
+		spec := &release.EditorRelease{Version: "2021.3.5f1"}
+		Expect(installer.InstallEditor("darwin", packageInstaller, spec, "Unity.pkg")).To(MatchError(ContainSubstring("--arch=x86_64")))
+		Expect(packageInstaller.options).To(BeEmpty())
+
+		spec.Architecture = release.ArchARM64
+		Expect(installer.InstallEditor("darwin", packageInstaller, spec, "Unity.pkg")).To(Succeed())
+	})
+})
@@ -0,0 +1,163 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	lockPollInterval = 500 * time.Millisecond
+	lockHeartbeat    = 30 * time.Second
+	lockStaleAfter   = 5 * time.Minute
+)
+
+// lockCount makes the owners of locks taken by this process unique.
+var lockCount uint64
+
+// fileLock is a cross-process lock backed by an exclusively created file.
+// Whilst held the lock file is touched periodically so that locks abandoned
+// by crashed processes can be detected and broken.
+//
+// The lock file holds the PID of the process which took it and a unique
+// suffix, so that a lock is only ever removed by its owner or by a process
+// breaking it once it is stale.
+type fileLock struct {
+	path  string
+	owner string
+	done  chan struct{}
+}
+
+func newLockOwner() string {
+	return fmt.Sprintf("%d-%d-%d", os.Getpid(), time.Now().UnixNano(), atomic.AddUint64(&lockCount, 1))
+}
+
+func readLockOwner(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	return strings.TrimSpace(string(data)), err
+}
+
+// removeLockIfOwner removes the lock file at path if it is held by owner.
+// The file is first renamed to a unique path, which only one process can do.
+// If another process took the lock in the meantime, its lock is put back.
+func removeLockIfOwner(path, owner string) (bool, error) {
+	aside := fmt.Sprintf("%s.%s.broken", path, newLockOwner())
+	if err := os.Rename(path, aside); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	actual, err := readLockOwner(aside)
+	if err == nil && actual != owner {
+		if err := os.Link(aside, path); err != nil && !os.IsExist(err) {
+			return false, fmt.Errorf("failed to restore lock %s: %w", path, err)
+		}
+	}
+
+	if rerr := os.Remove(aside); err == nil {
+		err = rerr
+	}
+
+	return err == nil && actual == owner, err
+}
+
+// breakStaleLock removes the lock file at path if its holder has stopped
+// heartbeating.
+func breakStaleLock(path string) error {
+	// The owner is read before checking the lock is stale: a lock taken
+	// since is fresh, so it can't be mistaken for the stale one.
+	owner, err := readLockOwner(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if isLocked(path) {
+		return nil
+	}
+
+	_, err = removeLockIfOwner(path, owner)
+	return err
+}
+
+func tryLockFile(path string) (*fileLock, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		// The holder may have stopped heartbeating, in which case the lock
+		// is broken and can be taken next time.
+		return nil, breakStaleLock(path)
+	}
+
+	owner := newLockOwner()
+	_, err = fmt.Fprintln(f, owner)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return nil, err
+	}
+
+	l := &fileLock{path, owner, make(chan struct{})}
+	go l.heartbeat()
+	return l, nil
+}
+
+func lockFile(ctx context.Context, path string) (*fileLock, error) {
+	for {
+		l, err := tryLockFile(path)
+		if l != nil || err != nil {
+			return l, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+func isLocked(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && time.Since(info.ModTime()) <= lockStaleAfter
+}
+
+func (l *fileLock) heartbeat() {
+	t := time.NewTicker(lockHeartbeat)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-l.done:
+			return
+		case now := <-t.C:
+			if owner, err := readLockOwner(l.path); err == nil && owner == l.owner {
+				_ = os.Chtimes(l.path, now, now)
+			}
+		}
+	}
+}
+
+func (l *fileLock) Unlock() error {
+	close(l.done)
+
+	owned, err := removeLockIfOwner(l.path, l.owner)
+	if err == nil && !owned {
+		err = fmt.Errorf("lock %s was broken whilst it was held", l.path)
+	}
+
+	return err
+}
@@ -0,0 +1,72 @@
+package installer
+
+import (
+	"context"
+	"sync"
+
+	"github.com/wellplayedgames/unity-installer/pkg/release"
+)
+
+// downloadPipeline downloads packages in order using a bounded pool of
+// workers, allowing each result to be consumed as soon as it is ready.
+type downloadPipeline struct {
+	downloads []*pipelineDownload
+	wg        sync.WaitGroup
+}
+
+type pipelineDownload struct {
+	pkg  *release.Package
+	done chan struct{}
+	path string
+	err  error
+}
+
+type downloadFunc func(ctx context.Context, pkg *release.Package) (string, error)
+
+func startDownloadPipeline(ctx context.Context, download downloadFunc, pkgs []*release.Package, workers int) *downloadPipeline {
+	if workers < 1 {
+		workers = 1
+	}
+
+	p := &downloadPipeline{}
+	queue := make(chan *pipelineDownload, len(pkgs))
+
+	for _, pkg := range pkgs {
+		d := &pipelineDownload{pkg: pkg, done: make(chan struct{})}
+		p.downloads = append(p.downloads, d)
+		queue <- d
+	}
+	close(queue)
+
+	for n := 0; n < workers && n < len(pkgs); n++ {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+
+			for d := range queue {
+				d.path, d.err = download(ctx, d.pkg)
+				close(d.done)
+			}
+		}()
+	}
+
+	return p
+}
+
+// Wait blocks until the idx-th package has been downloaded and returns its
+// local path.
+func (p *downloadPipeline) Wait(ctx context.Context, idx int) (string, error) {
+	d := p.downloads[idx]
+
+	select {
+	case <-d.done:
+		return d.path, d.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// Close waits for all workers to exit.
+func (p *downloadPipeline) Close() {
+	p.wg.Wait()
+}
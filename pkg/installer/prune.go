@@ -0,0 +1,146 @@
+package installer
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wellplayedgames/unity-installer/pkg/editor"
+	packageinstaller "github.com/wellplayedgames/unity-installer/pkg/package-installer"
+)
+
+// PrunePolicy decides which installed editors to remove. Editors matched by
+// any of the keep rules are kept. The rest are removed, except that if
+// UnusedFor is set only those which haven't been used for that long are.
+type PrunePolicy struct {
+	// KeepNewest keeps this many of the newest versions of each major
+	// version, such as 2021.3.
+	KeepNewest int
+
+	// KeepVersions keeps exact versions, such as those used by projects.
+	KeepVersions []string
+
+	// Pinned keeps the versions matched by any of these selectors.
+	Pinned []*editor.VersionSelector
+
+	// UnusedFor only removes editors which haven't been used for this long.
+	UnusedFor time.Duration
+}
+
+// PruneDecision is what a PrunePolicy decided to do with an editor, and why.
+type PruneDecision struct {
+	InstalledEditor
+	Remove bool   `json:"remove"`
+	Reason string `json:"reason"`
+}
+
+// majorVersion returns the major version of an editor, such as 2021.3 for
+// 2021.3.5f1.
+func majorVersion(version string) string {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return version
+	}
+
+	return parts[0] + "." + parts[1]
+}
+
+// newestPaths returns the paths of the newest editors of each major version
+// and architecture.
+func (p *PrunePolicy) newestPaths(editors []InstalledEditor) map[string]bool {
+	majors := map[string][]*InstalledEditor{}
+	for idx := range editors {
+		e := &editors[idx]
+		if editor.IsValidVersion(e.Version) {
+			key := majorVersion(e.Version) + "/" + e.Architecture
+			majors[key] = append(majors[key], e)
+		}
+	}
+
+	newest := map[string]bool{}
+	for _, versions := range majors {
+		sort.Slice(versions, func(a, b int) bool {
+			return editor.CompareVersions(versions[a].Version, versions[b].Version) > 0
+		})
+
+		for idx := 0; idx < len(versions) && idx < p.KeepNewest; idx++ {
+			newest[versions[idx].Path] = true
+		}
+	}
+
+	return newest
+}
+
+func (p *PrunePolicy) isPinned(version string) bool {
+	for _, selector := range p.Pinned {
+		if selector.Match(version, false) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func daysSince(now, t time.Time) int {
+	return int(now.Sub(t).Hours() / 24)
+}
+
+// Plan decides which of the given editors to remove.
+func (p *PrunePolicy) Plan(editors []InstalledEditor, now time.Time) []PruneDecision {
+	keepVersions := map[string]bool{}
+	for _, v := range p.KeepVersions {
+		keepVersions[v] = true
+	}
+
+	newest := p.newestPaths(editors)
+	decisions := make([]PruneDecision, len(editors))
+
+	for idx, e := range editors {
+		d := PruneDecision{InstalledEditor: e}
+
+		switch {
+		case !editor.IsValidVersion(e.Version):
+			d.Reason = "not a Unity version"
+		case keepVersions[e.Version]:
+			d.Reason = "used by a project"
+		case p.isPinned(e.Version):
+			d.Reason = "pinned"
+		case newest[e.Path]:
+			d.Reason = fmt.Sprintf("one of the %d newest %s versions", p.KeepNewest, majorVersion(e.Version))
+		case p.UnusedFor > 0 && now.Sub(e.LastUsed) < p.UnusedFor:
+			d.Reason = fmt.Sprintf("used %d days ago", daysSince(now, e.LastUsed))
+		case p.UnusedFor > 0:
+			d.Remove = true
+			d.Reason = fmt.Sprintf("unused for %d days", daysSince(now, e.LastUsed))
+		default:
+			d.Remove = true
+			d.Reason = "not kept by any policy"
+		}
+
+		decisions[idx] = d
+	}
+
+	return decisions
+}
+
+// PruneEditors removes the editors which were decided to be removed. Every
+// editor must be in dir.
+func PruneEditors(packageInstaller packageinstaller.PackageInstaller, dir string, decisions []PruneDecision) error {
+	for _, d := range decisions {
+		if !d.Remove {
+			continue
+		}
+
+		if filepath.Clean(d.Path) == filepath.Clean(dir) || !isWithinDir(dir, d.Path) {
+			return fmt.Errorf("refusing to remove %s, which is outside the install directory %s", d.Path, dir)
+		}
+
+		if err := packageInstaller.RemovePath(d.Path); err != nil {
+			return fmt.Errorf("failed to remove Unity %s: %w", d.Version, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package installer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/wellplayedgames/unity-installer/pkg/editor"
+)
+
+var _ = Describe("PrunePolicy", func() {
+	now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) time.Time {
+		return now.Add(-time.Duration(days) * 24 * time.Hour)
+	}
+
+	editors := []InstalledEditor{
+		{Version: "2019.4.9f1", Path: "/unity/2019.4.9f1", LastUsed: daysAgo(100)},
+		{Version: "2019.4.28f1", Path: "/unity/2019.4.28f1", LastUsed: daysAgo(50)},
+		{Version: "2019.4.20f1", Path: "/unity/2019.4.20f1", LastUsed: daysAgo(5)},
+		{Version: "2020.3.1f1", Path: "/unity/2020.3.1f1", LastUsed: daysAgo(200)},
+		{Version: "2020.3.1f1", Architecture: "arm64", Path: "/unity/2020.3.1f1-arm64", LastUsed: daysAgo(200)},
+		{Version: "Unity", Path: "/unity/Unity", LastUsed: daysAgo(400)},
+	}
+
+	removed := func(decisions []PruneDecision) []string {
+		var paths []string
+		for _, d := range decisions {
+			if d.Remove {
+				paths = append(paths, d.Path)
+			}
+		}
+		return paths
+	}
+
+	It("should keep the newest versions of each major version and architecture", func() {
+		policy := &PrunePolicy{KeepNewest: 1}
+		Expect(removed(policy.Plan(editors, now))).To(ConsistOf("/unity/2019.4.9f1", "/unity/2019.4.20f1"))
+	})
+
+	It("should compare build numbers and patch releases numerically", func() {
+		policy := &PrunePolicy{KeepNewest: 2}
+		newest := policy.newestPaths([]InstalledEditor{
+			{Version: "2019.4.9f1", Path: "/unity/2019.4.9f1"},
+			{Version: "2019.4.9f10", Path: "/unity/2019.4.9f10"},
+			{Version: "2019.4.40p1", Path: "/unity/2019.4.40p1"},
+			{Version: "2019.4.40f1", Path: "/unity/2019.4.40f1"},
+		})
+		Expect(newest).To(Equal(map[string]bool{
+			"/unity/2019.4.40p1": true,
+			"/unity/2019.4.40f1": true,
+		}))
+	})
+
+	It("should keep project and pinned versions", func() {
+		pin, err := editor.ParseVersionSelector("2020.3.x")
+		Expect(err).NotTo(HaveOccurred())
+
+		policy := &PrunePolicy{
+			KeepVersions: []string{"2019.4.9f1"},
+			Pinned:       []*editor.VersionSelector{pin},
+		}
+		Expect(removed(policy.Plan(editors, now))).To(ConsistOf("/unity/2019.4.28f1", "/unity/2019.4.20f1"))
+	})
+
+	It("should only remove unused editors when asked to", func() {
+		policy := &PrunePolicy{KeepNewest: 1, UnusedFor: 30 * 24 * time.Hour}
+		decisions := policy.Plan(editors, now)
+		Expect(removed(decisions)).To(ConsistOf("/unity/2019.4.9f1"))
+		Expect(decisions[0].Reason).To(Equal("unused for 100 days"))
+		Expect(decisions[2].Reason).To(Equal("used 5 days ago"))
+	})
+
+	It("should never remove unrecognised directories", func() {
+		policy := &PrunePolicy{UnusedFor: time.Hour}
+		Expect(removed(policy.Plan(editors, now))).NotTo(ContainElement("/unity/Unity"))
+	})
+})
+
+var _ = Describe("PruneEditors", func() {
+	var tempDir string
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "prune-test")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tempDir)).To(Succeed())
+	})
+
+	It("should remove only the editors to be removed", func() {
+		oldPath := filepath.Join(tempDir, "2019.4.9f1")
+		newPath := filepath.Join(tempDir, "2019.4.28f1")
+		Expect(os.MkdirAll(oldPath, os.ModePerm)).To(Succeed())
+		Expect(os.MkdirAll(newPath, os.ModePerm)).To(Succeed())
+
+		packageInstaller := &recordingPackageInstaller{}
+		err := PruneEditors(packageInstaller, tempDir, []PruneDecision{
+			{InstalledEditor: InstalledEditor{Version: "2019.4.9f1", Path: oldPath}, Remove: true},
+			{InstalledEditor: InstalledEditor{Version: "2019.4.28f1", Path: newPath}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(packageInstaller.removed).To(Equal([]string{oldPath}))
+		Expect(newPath).To(BeADirectory())
+	})
+
+	It("should refuse to remove editors outside the install directory", func() {
+		packageInstaller := &recordingPackageInstaller{}
+		err := PruneEditors(packageInstaller, tempDir, []PruneDecision{
+			{InstalledEditor: InstalledEditor{Version: "2019.4.9f1", Path: filepath.Dir(tempDir)}, Remove: true},
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(packageInstaller.removed).To(BeEmpty())
+	})
+})
@@ -0,0 +1,105 @@
+package installer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	regexRulePrefix = "regex:"
+	ruleArrow       = " => "
+)
+
+// RewriteRule redirects download URLs, for example to an internal mirror.
+type RewriteRule struct {
+	prefix      string
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// ParseRewriteRule parses a rule of the form "from=to", or "from => to" if
+// from contains "=". By default from is a URL prefix which is replaced with
+// to. If from starts with "regex:" the rest is a regular expression and to may
+// reference its capture groups as $1 etc.
+func ParseRewriteRule(rule string) (RewriteRule, error) {
+	sep := ruleArrow
+	idx := strings.Index(rule, sep)
+	if idx < 0 {
+		sep = "="
+		idx = strings.Index(rule, sep)
+	}
+	if idx <= 0 {
+		return RewriteRule{}, fmt.Errorf("invalid rewrite rule %q: expected from=to or from => to", rule)
+	}
+
+	from, to := rule[:idx], rule[idx+len(sep):]
+	if !strings.HasPrefix(from, regexRulePrefix) {
+		return RewriteRule{prefix: from, replacement: to}, nil
+	}
+
+	pattern, err := regexp.Compile(from[len(regexRulePrefix):])
+	if err != nil {
+		return RewriteRule{}, fmt.Errorf("invalid rewrite rule %q: %w", rule, err)
+	}
+
+	return RewriteRule{pattern: pattern, replacement: to}, nil
+}
+
+// LoadRewriteRules reads rewrite rules from a file, one per line. Blank lines
+// and lines starting with # are ignored.
+func LoadRewriteRules(path string) ([]RewriteRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rules []RewriteRule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule, err := ParseRewriteRule(line)
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, scanner.Err()
+}
+
+// Apply returns the rewritten URL and true if the rule matches url.
+func (r RewriteRule) Apply(url string) (string, bool) {
+	if r.pattern != nil {
+		if !r.pattern.MatchString(url) {
+			return "", false
+		}
+
+		return r.pattern.ReplaceAllString(url, r.replacement), true
+	}
+
+	if !strings.HasPrefix(url, r.prefix) {
+		return "", false
+	}
+
+	return r.replacement + url[len(r.prefix):], true
+}
+
+// RewriteURL applies the first matching rule to url.
+func RewriteURL(rules []RewriteRule, url string) (string, bool) {
+	for _, rule := range rules {
+		if rewritten, ok := rule.Apply(url); ok {
+			return rewritten, true
+		}
+	}
+
+	return url, false
+}
@@ -0,0 +1,55 @@
+package installer
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RewriteURL", func() {
+	It("should apply the first matching rule", func() {
+		var rules []RewriteRule
+		for _, s := range []string{
+			"https://dl.google.com/android/=https://mirror.example.com/google/android/",
+			`regex:^https?://download\.unity3d\.com/(.*)$=https://mirror.example.com/unity/$1`,
+		} {
+			rule, err := ParseRewriteRule(s)
+			Expect(err).NotTo(HaveOccurred())
+			rules = append(rules, rule)
+		}
+
+		url, ok := RewriteURL(rules, "https://dl.google.com/android/repository/platform-29_r05.zip")
+		Expect(ok).To(BeTrue())
+		Expect(url).To(Equal("https://mirror.example.com/google/android/repository/platform-29_r05.zip"))
+
+		url, ok = RewriteURL(rules, "http://download.unity3d.com/download_unity/open-jdk/jdk.zip")
+		Expect(ok).To(BeTrue())
+		Expect(url).To(Equal("https://mirror.example.com/unity/download_unity/open-jdk/jdk.zip"))
+
+		url, ok = RewriteURL(rules, "https://beta.unity3d.com/download/Unity.exe")
+		Expect(ok).To(BeFalse())
+		Expect(url).To(Equal("https://beta.unity3d.com/download/Unity.exe"))
+	})
+
+	It("should split rules on => when the URL contains =", func() {
+		rule, err := ParseRewriteRule(`regex:^https://example\.com/get\?file=(.*)$ => https://mirror.example.com/$1?from=example`)
+		Expect(err).NotTo(HaveOccurred())
+
+		url, ok := rule.Apply("https://example.com/get?file=Unity.zip")
+		Expect(ok).To(BeTrue())
+		Expect(url).To(Equal("https://mirror.example.com/Unity.zip?from=example"))
+
+		rule, err = ParseRewriteRule("https://example.com/?a=b => https://mirror.example.com/")
+		Expect(err).NotTo(HaveOccurred())
+
+		url, ok = rule.Apply("https://example.com/?a=b&c=d")
+		Expect(ok).To(BeTrue())
+		Expect(url).To(Equal("https://mirror.example.com/&c=d"))
+	})
+
+	It("should reject malformed rules", func() {
+		_, err := ParseRewriteRule("https://dl.google.com/")
+		Expect(err).To(HaveOccurred())
+		_, err = ParseRewriteRule("regex:(=x")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,279 @@
+package installer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	packageinstaller "github.com/wellplayedgames/unity-installer/pkg/package-installer"
+	"github.com/wellplayedgames/unity-installer/pkg/release"
+)
+
+// resolveInstallPath returns the absolute path of an install option such as
+// a module destination, which may refer to the editor as {UNITY_PATH}.
+func resolveInstallPath(editorPath, p string) string {
+	return filepath.Clean(strings.ReplaceAll(p, "{UNITY_PATH}", editorPath))
+}
+
+// editorOwnedPaths are paths, relative to an editor directory, which hold
+// the editor itself rather than any one module.
+var editorOwnedPaths = []string{
+	filepath.Join("Editor", "Data"),
+	"Unity.app",
+}
+
+// moduleRemovePaths returns the paths to remove to uninstall a module. These
+// are the files in its manifest if it has one, or otherwise the directory it
+// was installed into, which must belong to the module alone. Every path must
+// be inside the editor directory.
+func moduleRemovePaths(editorPath string, m *release.ModuleRelease, modules []release.ModuleRelease) ([]string, error) {
+	manifest, err := packageinstaller.ReadManifest(editorPath, m.ID)
+	if err == nil {
+		paths := make([]string, 0, len(manifest.Files)+1)
+		for _, f := range manifest.Files {
+			p := filepath.Join(editorPath, filepath.FromSlash(f.Path))
+			if p == editorPath || !isWithinDir(editorPath, p) {
+				return nil, fmt.Errorf("refusing to remove %s, which is outside the editor directory %s", p, editorPath)
+			}
+			paths = append(paths, p)
+		}
+
+		return append(paths, packageinstaller.ManifestPath(editorPath, m.ID)), nil
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read manifest of module %s: %w", m.ID, err)
+	}
+
+	p, err := moduleInstallPath(editorPath, m, modules)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{p}, nil
+}
+
+// moduleDirectory returns the directory a module was installed into, or ""
+// if it was installed by its own installer.
+func moduleDirectory(editorPath string, m *release.ModuleRelease) string {
+	switch {
+	case m.RenameTo != nil:
+		return resolveInstallPath(editorPath, *m.RenameTo)
+	case m.Destination != nil:
+		return resolveInstallPath(editorPath, *m.Destination)
+	default:
+		return ""
+	}
+}
+
+// moduleInstallPath returns the directory a module without a manifest was
+// installed into. It refuses directories which are shared with the editor or
+// with any other module of the release, since there is no telling which of
+// their files are the module's.
+func moduleInstallPath(editorPath string, m *release.ModuleRelease, modules []release.ModuleRelease) (string, error) {
+	dir := moduleDirectory(editorPath, m)
+	if dir == "" {
+		return "", fmt.Errorf("module %s was installed by its own installer and cannot be removed", m.ID)
+	}
+
+	if dir == editorPath {
+		return "", fmt.Errorf("module %s is installed into the editor directory and cannot be removed on its own", m.ID)
+	}
+
+	if !isWithinDir(editorPath, dir) {
+		return "", fmt.Errorf("refusing to remove %s, which is outside the editor directory %s", dir, editorPath)
+	}
+
+	owned := append([]string{}, editorOwnedPaths...)
+	for _, executable := range editorExecutables {
+		owned = append(owned, executable.path)
+	}
+
+	for _, p := range owned {
+		if isWithinDir(dir, filepath.Join(editorPath, p)) {
+			return "", fmt.Errorf("module %s shares %s with the editor and has no manifest, so cannot be removed", m.ID, dir)
+		}
+	}
+
+	for idx := range modules {
+		other := &modules[idx]
+		if other.ID == m.ID {
+			continue
+		}
+
+		// Modules installed into the editor directory itself, or outside it,
+		// are refused on their own and don't claim any of its directories.
+		otherDir := moduleDirectory(editorPath, other)
+		if otherDir == "" || otherDir == editorPath || !isWithinDir(editorPath, otherDir) {
+			continue
+		}
+
+		if isWithinDir(dir, otherDir) || isWithinDir(otherDir, dir) {
+			return "", fmt.Errorf("module %s shares %s with module %s and has no manifest, so cannot be removed", m.ID, dir, other.ID)
+		}
+	}
+
+	return dir, nil
+}
+
+// modulesToUninstall returns the IDs of the installed modules to remove:
+// those requested and, since their files may live inside those of the
+// modules they depend on, every installed module which depends on them.
+func modulesToUninstall(installed []release.ModuleRelease, moduleIDs []string) ([]string, error) {
+	byID := map[string]*release.ModuleRelease{}
+	for idx := range installed {
+		if installed[idx].Selected {
+			byID[installed[idx].ID] = &installed[idx]
+		}
+	}
+
+	removing := map[string]bool{}
+	var ordered []string
+
+	for _, id := range moduleIDs {
+		if byID[id] == nil {
+			return nil, fmt.Errorf("module %s is not installed", id)
+		}
+
+		if !removing[id] {
+			removing[id] = true
+			ordered = append(ordered, id)
+		}
+	}
+
+	// Keep adding dependents until there are no more.
+	for changed := true; changed; {
+		changed = false
+
+		for idx := range installed {
+			m := &installed[idx]
+			if !m.Selected || removing[m.ID] {
+				continue
+			}
+
+			for _, dep := range m.Requires() {
+				if removing[dep] {
+					removing[m.ID] = true
+					ordered = append(ordered, m.ID)
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	return ordered, nil
+}
+
+// pruneEmptyDirectories removes directories inside the editor directory
+// which were left empty by removing paths.
+func pruneEmptyDirectories(packageInstaller packageinstaller.PackageInstaller, editorPath string, removed []string) error {
+	dirs := map[string]bool{}
+	for _, p := range removed {
+		for dir := filepath.Dir(p); dir != editorPath && isWithinDir(editorPath, dir) && !dirs[dir]; dir = filepath.Dir(dir) {
+			dirs[dir] = true
+		}
+	}
+
+	sorted := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		sorted = append(sorted, dir)
+	}
+
+	// Remove the deepest directories first so that their parents can be
+	// empty too.
+	sort.Slice(sorted, func(a, b int) bool {
+		return len(sorted[a]) > len(sorted[b])
+	})
+
+	for _, dir := range sorted {
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil || len(entries) > 0 {
+			continue
+		}
+
+		if err := packageInstaller.RemovePath(dir); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UninstallEditor removes an installed editor along with all of its modules.
+func (i *simpleInstaller) UninstallEditor(packageInstaller packageinstaller.PackageInstaller, editorVersion string) error {
+	found, _, err := i.CheckEditorVersion(editorVersion)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return fmt.Errorf("Unity %s is not installed", editorVersion)
+	}
+
+	editorPath := i.EditorPath(editorVersion)
+	if editorPath == filepath.Clean(i.editorDir) || !isWithinDir(i.editorDir, editorPath) {
+		return fmt.Errorf("refusing to remove %s, which is outside the install directory %s", editorPath, i.editorDir)
+	}
+
+	return packageInstaller.RemovePath(editorPath)
+}
+
+// UninstallModules removes the files of installed modules, along with any
+// modules which depend on them, and marks them as no longer selected. It
+// returns the IDs of the modules removed.
+func (i *simpleInstaller) UninstallModules(packageInstaller packageinstaller.PackageInstaller, editorVersion string, moduleIDs []string) ([]string, error) {
+	found, installed, err := i.CheckEditorVersion(editorVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, fmt.Errorf("Unity %s is not installed", editorVersion)
+	}
+
+	removeIDs, err := modulesToUninstall(installed, moduleIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check every module before removing anything.
+	editorPath := i.EditorPath(editorVersion)
+	var removePaths []string
+
+	for _, id := range removeIDs {
+		for mIdx := range installed {
+			if installed[mIdx].ID == id {
+				paths, err := moduleRemovePaths(editorPath, &installed[mIdx], installed)
+				if err != nil {
+					return nil, err
+				}
+				removePaths = append(removePaths, paths...)
+			}
+		}
+	}
+
+	for _, p := range removePaths {
+		if err := packageInstaller.RemovePath(p); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := pruneEmptyDirectories(packageInstaller, editorPath, removePaths); err != nil {
+		return nil, err
+	}
+
+	removing := map[string]bool{}
+	for _, id := range removeIDs {
+		removing[id] = true
+	}
+
+	for idx := range installed {
+		if removing[installed[idx].ID] {
+			installed[idx].Selected = false
+		}
+	}
+
+	return removeIDs, packageInstaller.StoreModules(editorPath, installed)
+}
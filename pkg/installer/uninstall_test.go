@@ -0,0 +1,183 @@
+package installer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	packageinstaller "github.com/wellplayedgames/unity-installer/pkg/package-installer"
+	"github.com/wellplayedgames/unity-installer/pkg/release"
+)
+
+var _ = Describe("Uninstall", func() {
+	var (
+		tempDir          string
+		editorPath       string
+		installer        *simpleInstaller
+		packageInstaller *recordingPackageInstaller
+	)
+
+	module := func(id, destination string, selected bool) release.ModuleRelease {
+		m := release.ModuleRelease{ID: id, Selected: selected}
+		m.Destination = &destination
+		return m
+	}
+
+	writeEditor := func(modules ...release.ModuleRelease) {
+		Expect(os.MkdirAll(filepath.Join(editorPath, "Editor", "Data", "PlaybackEngines", "AndroidPlayer", "SDK"), os.ModePerm)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(editorPath, "Editor", "Data", "PlaybackEngines", "WebGLSupport"), os.ModePerm)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(editorPath, "Editor", "Unity"), nil, 0755)).To(Succeed())
+
+		b, err := json.Marshal(modules)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(editorPath, packageinstaller.ModulesFile), b, 0644)).To(Succeed())
+	}
+
+	writeManifest := func(id string, files ...string) {
+		for _, f := range files {
+			p := filepath.Join(editorPath, filepath.FromSlash(f))
+			Expect(os.MkdirAll(filepath.Dir(p), os.ModePerm)).To(Succeed())
+			Expect(ioutil.WriteFile(p, []byte(id), 0644)).To(Succeed())
+		}
+
+		manifest := &packageinstaller.Manifest{Name: id}
+		for _, f := range files {
+			manifest.Files = append(manifest.Files, packageinstaller.ManifestFile{Path: f})
+		}
+
+		b, err := json.Marshal(manifest)
+		Expect(err).NotTo(HaveOccurred())
+		manifestPath := packageinstaller.ManifestPath(editorPath, id)
+		Expect(os.MkdirAll(filepath.Dir(manifestPath), os.ModePerm)).To(Succeed())
+		Expect(ioutil.WriteFile(manifestPath, b, 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "uninstall-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		editorPath = filepath.Join(tempDir, "2019.4.9f1")
+		installer = &simpleInstaller{editorDir: tempDir}
+		packageInstaller = &recordingPackageInstaller{}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tempDir)).To(Succeed())
+	})
+
+	It("should remove a whole editor", func() {
+		writeEditor()
+
+		Expect(installer.UninstallEditor(packageInstaller, "2019.4.9f1")).To(Succeed())
+		Expect(editorPath).NotTo(BeADirectory())
+		Expect(installer.UninstallEditor(packageInstaller, "2019.4.9f1")).NotTo(Succeed())
+	})
+
+	It("should remove modules and the modules which depend on them", func() {
+		writeEditor(
+			module("android", "{UNITY_PATH}/Editor/Data/PlaybackEngines/AndroidPlayer", true),
+			module("android-sdk-platform-tools", "{UNITY_PATH}/Editor/Data/PlaybackEngines/AndroidPlayer/SDK", true),
+			module("webgl", "{UNITY_PATH}/Editor/Data/PlaybackEngines/WebGLSupport", true),
+		)
+		writeManifest("android", "Editor/Data/PlaybackEngines/AndroidPlayer/UnityEditor.Android.Extensions.dll")
+		writeManifest("android-sdk-platform-tools", "Editor/Data/PlaybackEngines/AndroidPlayer/SDK/platform-tools/adb")
+
+		removed, err := installer.UninstallModules(packageInstaller, "2019.4.9f1", []string{"android"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(removed).To(Equal([]string{"android", "android-sdk-platform-tools"}))
+		Expect(filepath.Join(editorPath, "Editor", "Data", "PlaybackEngines", "AndroidPlayer")).NotTo(BeADirectory())
+		Expect(filepath.Join(editorPath, "Editor", "Data", "PlaybackEngines", "WebGLSupport")).To(BeADirectory())
+
+		selected := map[string]bool{}
+		for _, m := range packageInstaller.modules {
+			selected[m.ID] = m.Selected
+		}
+		Expect(selected).To(Equal(map[string]bool{"android": false, "android-sdk-platform-tools": false, "webgl": true}))
+	})
+
+	It("should refuse to remove paths outside the editor directory", func() {
+		writeEditor(
+			module("webgl", "{UNITY_PATH}/Editor/Data/PlaybackEngines/WebGLSupport", true),
+			module("evil", "{UNITY_PATH}/..", true),
+		)
+
+		_, err := installer.UninstallModules(packageInstaller, "2019.4.9f1", []string{"webgl", "evil"})
+		Expect(err).To(MatchError(ContainSubstring("outside the editor directory")))
+		Expect(packageInstaller.removed).To(BeEmpty())
+		Expect(filepath.Join(editorPath, "Editor", "Data", "PlaybackEngines", "WebGLSupport")).To(BeADirectory())
+	})
+
+	It("should refuse to remove modules installed into the editor directory", func() {
+		writeEditor(module("documentation", "{UNITY_PATH}", true))
+
+		_, err := installer.UninstallModules(packageInstaller, "2019.4.9f1", []string{"documentation"})
+		Expect(err).To(HaveOccurred())
+		Expect(editorPath).To(BeADirectory())
+	})
+
+	It("should refuse to remove directories shared with the editor or other modules without a manifest", func() {
+		writeEditor(
+			module("standardassets", "{UNITY_PATH}/Editor", true),
+			module("documentation", "{UNITY_PATH}/Editor/Data", true),
+			module("language-ja", "{UNITY_PATH}/Editor/Data/Localization", true),
+			module("language-ko", "{UNITY_PATH}/Editor/Data/Localization", true),
+			module("android-sdk-ndk-tools", "{UNITY_PATH}/Editor/Data/PlaybackEngines/AndroidPlayer/SDK", true),
+			module("android-sdk-platform-tools", "{UNITY_PATH}/Editor/Data/PlaybackEngines/AndroidPlayer/SDK", true),
+		)
+
+		for _, id := range []string{"standardassets", "documentation", "language-ja", "android-sdk-ndk-tools"} {
+			_, err := installer.UninstallModules(packageInstaller, "2019.4.9f1", []string{id})
+			Expect(err).To(MatchError(ContainSubstring("has no manifest")), id)
+		}
+
+		Expect(packageInstaller.removed).To(BeEmpty())
+		Expect(filepath.Join(editorPath, "Editor", "Unity")).To(BeAnExistingFile())
+		Expect(filepath.Join(editorPath, "Editor", "Data", "PlaybackEngines", "AndroidPlayer", "SDK")).To(BeADirectory())
+	})
+
+	It("should remove a directory which belongs to a module alone without a manifest", func() {
+		writeEditor(
+			module("android", "{UNITY_PATH}/Editor/Data/PlaybackEngines/AndroidPlayer", true),
+			module("webgl", "{UNITY_PATH}/Editor/Data/PlaybackEngines/WebGLSupport", true),
+		)
+
+		_, err := installer.UninstallModules(packageInstaller, "2019.4.9f1", []string{"webgl"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(filepath.Join(editorPath, "Editor", "Data", "PlaybackEngines", "WebGLSupport")).NotTo(BeADirectory())
+		Expect(filepath.Join(editorPath, "Editor", "Data", "PlaybackEngines", "AndroidPlayer")).To(BeADirectory())
+	})
+
+	It("should remove the files in a module's manifest", func() {
+		writeEditor(module("documentation", "{UNITY_PATH}", true))
+
+		docsPath := filepath.Join(editorPath, "Editor", "Data", "Documentation", "index.html")
+		Expect(os.MkdirAll(filepath.Dir(docsPath), os.ModePerm)).To(Succeed())
+		Expect(ioutil.WriteFile(docsPath, []byte("docs"), 0644)).To(Succeed())
+
+		manifest, err := json.Marshal(&packageinstaller.Manifest{
+			Name:  "documentation",
+			Files: []packageinstaller.ManifestFile{{Path: "Editor/Data/Documentation/index.html"}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		manifestPath := packageinstaller.ManifestPath(editorPath, "documentation")
+		Expect(os.MkdirAll(filepath.Dir(manifestPath), os.ModePerm)).To(Succeed())
+		Expect(ioutil.WriteFile(manifestPath, manifest, 0644)).To(Succeed())
+
+		_, err = installer.UninstallModules(packageInstaller, "2019.4.9f1", []string{"documentation"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(filepath.Join(editorPath, "Editor", "Data", "Documentation")).NotTo(BeADirectory())
+		Expect(manifestPath).NotTo(BeAnExistingFile())
+		Expect(filepath.Join(editorPath, "Editor", "Unity")).To(BeAnExistingFile())
+	})
+
+	It("should reject modules which are not installed", func() {
+		writeEditor(module("webgl", "{UNITY_PATH}/Editor/Data/PlaybackEngines/WebGLSupport", false))
+
+		_, err := installer.UninstallModules(packageInstaller, "2019.4.9f1", []string{"webgl"})
+		Expect(err).To(MatchError("module webgl is not installed"))
+	})
+})
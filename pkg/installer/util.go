@@ -1,7 +1,7 @@
 package installer
 
 import (
-	"fmt"
+	"context"
 
 	packageinstaller "github.com/wellplayedgames/unity-installer/pkg/package-installer"
 	"github.com/wellplayedgames/unity-installer/pkg/release"
@@ -32,7 +32,15 @@ func HasEditorAndModules(installer UnityInstaller, editorVersion string, moduleI
 }
 
 // EnsureEditorWithModules installs (if missing) an editor version and list of modules.
+//
+// Packages are downloaded concurrently by up to parallelDownloads workers
+// whilst being installed one at a time: the editor first, then modules in the
+// order given except that any modules they depend on are installed before
+// them. If the installer is a DiskSpaceChecker, disk space is checked before
+// anything is downloaded, and if it is an ArchitectureChecker, releases for
+// another architecture are refused.
 func EnsureEditorWithModules(
+	ctx context.Context,
 	platform string,
 	unityInstaller UnityInstaller,
 	packageInstaller packageinstaller.PackageInstaller,
@@ -40,18 +48,30 @@ func EnsureEditorWithModules(
 	moduleIDs []string,
 	force bool,
 	skipEditor bool,
-	) error {
+	parallelDownloads int,
+) error {
+
+	if checker, ok := unityInstaller.(ArchitectureChecker); ok {
+		if err := checker.CheckArchitecture(editorRelease); err != nil {
+			return err
+		}
+	}
 
 	hasEditor, existingModules, err := unityInstaller.CheckEditorVersion(editorRelease.Version)
 	if err != nil {
 		return err
 	}
 
+	type installStep struct {
+		pkg     *release.Package
+		install func(packagePath string) error
+	}
+	var steps []installStep
+
 	if !skipEditor && (force || !hasEditor) {
-		err = unityInstaller.InstallEditor(platform, packageInstaller, editorRelease)
-		if err != nil {
-			return err
-		}
+		steps = append(steps, installStep{&editorRelease.Package, func(packagePath string) error {
+			return unityInstaller.InstallEditor(platform, packageInstaller, editorRelease, packagePath)
+		}})
 	}
 
 	existingModSet := map[string]bool{}
@@ -63,30 +83,55 @@ func EnsureEditorWithModules(
 		}
 	}
 
-	availableModMap := map[string]*release.ModuleRelease{}
-
-	for idx := range editorRelease.Modules {
-		m := &editorRelease.Modules[idx]
-		availableModMap[m.ID] = m
+	modules, err := ResolveModules(editorRelease, moduleIDs)
+	if err != nil {
+		return err
 	}
 
+	requestedModSet := map[string]bool{}
 	for _, moduleID := range moduleIDs {
-		if !force && existingModSet[moduleID] {
+		requestedModSet[moduleID] = true
+	}
+
+	for _, m := range modules {
+		// Dependencies are only reinstalled if they were requested too.
+		if existingModSet[m.ID] && !(force && requestedModSet[m.ID]) {
 			continue
 		}
 
-		m, ok := availableModMap[moduleID]
+		m := m
+		steps = append(steps, installStep{&m.Package, func(packagePath string) error {
+			return unityInstaller.InstallModule(packageInstaller, editorRelease.Version, m, packagePath)
+		}})
+	}
+
+	pkgs := make([]*release.Package, len(steps))
+	for idx := range steps {
+		pkgs[idx] = steps[idx].pkg
+	}
 
-		if !ok {
-			return fmt.Errorf("Missing module %s", moduleID)
+	if checker, ok := unityInstaller.(DiskSpaceChecker); ok {
+		if err := checker.CheckDiskSpace(pkgs); err != nil {
+			return err
 		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	pipeline := startDownloadPipeline(ctx, unityInstaller.DownloadPackage, pkgs, parallelDownloads)
+	defer func() {
+		cancel()
+		pipeline.Close()
+	}()
 
-		err = unityInstaller.InstallModule(packageInstaller, editorRelease.Version, m)
+	for idx, step := range steps {
+		packagePath, err := pipeline.Wait(ctx, idx)
 		if err != nil {
 			return err
 		}
 
-		existingModSet[moduleID] = true
+		if err := step.install(packagePath); err != nil {
+			return err
+		}
 	}
 
 	return nil
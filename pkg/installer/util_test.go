@@ -0,0 +1,135 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	packageinstaller "github.com/wellplayedgames/unity-installer/pkg/package-installer"
+	"github.com/wellplayedgames/unity-installer/pkg/release"
+)
+
+type fakeUnityInstaller struct {
+	lock       sync.Mutex
+	downloaded []string
+	installed  []string
+	failURL    string
+
+	// If set, the editor download is held back until a module has downloaded.
+	holdEditor  chan struct{}
+	releaseOnce sync.Once
+}
+
+func (f *fakeUnityInstaller) Close() error {
+	return nil
+}
+
+func (f *fakeUnityInstaller) DownloadPackage(ctx context.Context, pkg *release.Package) (string, error) {
+	if pkg.DownloadURL == f.failURL {
+		return "", errors.New("download failed")
+	}
+
+	if pkg.DownloadURL == "editor" && f.holdEditor != nil {
+		select {
+		case <-f.holdEditor:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	f.lock.Lock()
+	f.downloaded = append(f.downloaded, pkg.DownloadURL)
+	f.lock.Unlock()
+
+	if pkg.DownloadURL != "editor" && f.holdEditor != nil {
+		f.releaseOnce.Do(func() { close(f.holdEditor) })
+	}
+
+	return pkg.DownloadURL, nil
+}
+
+func (f *fakeUnityInstaller) InstallEditor(platform string, installer packageinstaller.PackageInstaller, spec *release.EditorRelease, packagePath string) error {
+	f.installed = append(f.installed, packagePath)
+	return nil
+}
+
+func (f *fakeUnityInstaller) InstallModule(installer packageinstaller.PackageInstaller, editorVersion string, spec *release.ModuleRelease, packagePath string) error {
+	f.installed = append(f.installed, packagePath)
+	return nil
+}
+
+func (f *fakeUnityInstaller) CheckEditorVersion(editorVersion string) (bool, []release.ModuleRelease, error) {
+	return false, nil, nil
+}
+
+func (f *fakeUnityInstaller) EditorPath(editorVersion string) string {
+	return editorVersion
+}
+
+func (f *fakeUnityInstaller) UninstallEditor(installer packageinstaller.PackageInstaller, editorVersion string) error {
+	return nil
+}
+
+func (f *fakeUnityInstaller) UninstallModules(installer packageinstaller.PackageInstaller, editorVersion string, moduleIDs []string) ([]string, error) {
+	return moduleIDs, nil
+}
+
+var _ = Describe("EnsureEditorWithModules", func() {
+	var editorRelease *release.EditorRelease
+
+	BeforeEach(func() {
+		editorRelease = &release.EditorRelease{Version: "2019.4.9f1"}
+		editorRelease.DownloadURL = "editor"
+		for _, id := range []string{"android", "ios", "webgl"} {
+			m := release.ModuleRelease{ID: id}
+			m.DownloadURL = id
+			editorRelease.Modules = append(editorRelease.Modules, m)
+		}
+	})
+
+	It("should install in order whilst downloading concurrently", func() {
+		fake := &fakeUnityInstaller{holdEditor: make(chan struct{})}
+		err := EnsureEditorWithModules(context.Background(), "win32", fake, nil, editorRelease, []string{"webgl", "android"}, false, false, 3)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fake.downloaded[0]).NotTo(Equal("editor"))
+		Expect(fake.installed).To(Equal([]string{"editor", "webgl", "android"}))
+	})
+
+	It("should stop at the first failed download", func() {
+		fake := &fakeUnityInstaller{failURL: "ios"}
+		err := EnsureEditorWithModules(context.Background(), "win32", fake, nil, editorRelease, []string{"android", "ios", "webgl"}, false, false, 2)
+		Expect(err).To(MatchError("download failed"))
+		Expect(fake.installed).To(Equal([]string{"editor", "android"}))
+	})
+
+	It("should install dependencies before the modules which need them", func() {
+		ndk := release.ModuleRelease{ID: "android-ndk"}
+		ndk.DownloadURL = "android-ndk"
+		editorRelease.Modules = append(editorRelease.Modules, ndk)
+
+		fake := &fakeUnityInstaller{}
+		err := EnsureEditorWithModules(context.Background(), "win32", fake, nil, editorRelease, []string{"webgl", "android-ndk"}, false, false, 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fake.installed).To(Equal([]string{"editor", "webgl", "android", "android-ndk"}))
+	})
+
+	It("should reject missing dependencies and cycles", func() {
+		editorRelease.Modules[0].Dependencies = []string{"switch"}
+		_, err := ResolveModules(editorRelease, []string{"android"})
+		Expect(err).To(MatchError("module android requires switch, which is not available for 2019.4.9f1"))
+
+		editorRelease.Modules[0].Dependencies = []string{"ios"}
+		editorRelease.Modules[1].Parent = "android"
+		_, err = ResolveModules(editorRelease, []string{"android"})
+		Expect(err).To(MatchError("module dependency cycle: android -> ios -> android"))
+	})
+
+	It("should reject unknown modules before downloading", func() {
+		fake := &fakeUnityInstaller{}
+		err := EnsureEditorWithModules(context.Background(), "win32", fake, nil, editorRelease, []string{"android", "switch"}, false, false, 2)
+		Expect(err).To(HaveOccurred())
+		Expect(fake.downloaded).To(BeEmpty())
+	})
+})
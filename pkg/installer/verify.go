@@ -0,0 +1,141 @@
+package installer
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/wellplayedgames/unity-installer/pkg/release"
+)
+
+// ChecksumMismatchError is returned when a downloaded package does not match
+// the checksum or size recorded in its spec.
+type ChecksumMismatchError struct {
+	URL       string
+	Algorithm string
+	Expected  string
+	Actual    string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("%s mismatch for %s: expected %s, got %s", e.Algorithm, e.URL, e.Expected, e.Actual)
+}
+
+type expectedHash struct {
+	algorithm string
+	expected  string
+	hash      hash.Hash
+}
+
+// packageVerifier hashes package data as it is written and checks it against
+// the package spec.
+type packageVerifier struct {
+	url          string
+	expectedSize int64
+	size         int64
+	hashes       []expectedHash
+	err          error
+}
+
+// parseIntegrity parses a subresource integrity string such as
+// "sha384-<base64 digest>".
+func parseIntegrity(integrity string) (expectedHash, error) {
+	idx := strings.IndexByte(integrity, '-')
+	if idx < 0 {
+		return expectedHash{}, fmt.Errorf("invalid integrity %q", integrity)
+	}
+
+	algorithm := strings.ToLower(integrity[:idx])
+	digest, err := base64.StdEncoding.DecodeString(integrity[idx+1:])
+	if err != nil {
+		return expectedHash{}, fmt.Errorf("invalid integrity %q: %w", integrity, err)
+	}
+
+	var h hash.Hash
+	switch algorithm {
+	case "md5":
+		h = md5.New()
+	case "sha256":
+		h = sha256.New()
+	case "sha384":
+		h = sha512.New384()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return expectedHash{}, fmt.Errorf("unsupported integrity algorithm %s", algorithm)
+	}
+
+	return expectedHash{algorithm, hex.EncodeToString(digest), h}, nil
+}
+
+func newPackageVerifier(pkg *release.Package) *packageVerifier {
+	v := &packageVerifier{url: pkg.DownloadURL}
+	if !pkg.ApproximateDownloadSize {
+		v.expectedSize = int64(pkg.DownloadSize)
+	}
+
+	if pkg.Checksum != "" {
+		v.hashes = append(v.hashes, expectedHash{"md5", pkg.Checksum, md5.New()})
+	}
+
+	if pkg.SHA256 != "" {
+		v.hashes = append(v.hashes, expectedHash{"sha256", pkg.SHA256, sha256.New()})
+	}
+
+	if pkg.Integrity != "" {
+		h, err := parseIntegrity(pkg.Integrity)
+		if err != nil {
+			v.err = err
+		} else {
+			v.hashes = append(v.hashes, h)
+		}
+	}
+
+	return v
+}
+
+func (v *packageVerifier) Write(p []byte) (int, error) {
+	v.size += int64(len(p))
+
+	for _, h := range v.hashes {
+		h.hash.Write(p)
+	}
+
+	return len(p), nil
+}
+
+// Verify returns a ChecksumMismatchError if the data written does not match
+// the package spec.
+func (v *packageVerifier) Verify() error {
+	if v.err != nil {
+		return v.err
+	}
+
+	if v.expectedSize > 0 && v.size != v.expectedSize {
+		return &ChecksumMismatchError{
+			URL:       v.url,
+			Algorithm: "size",
+			Expected:  fmt.Sprint(v.expectedSize),
+			Actual:    fmt.Sprint(v.size),
+		}
+	}
+
+	for _, h := range v.hashes {
+		actual := hex.EncodeToString(h.hash.Sum(nil))
+		if !strings.EqualFold(actual, h.expected) {
+			return &ChecksumMismatchError{
+				URL:       v.url,
+				Algorithm: h.algorithm,
+				Expected:  h.expected,
+				Actual:    actual,
+			}
+		}
+	}
+
+	return nil
+}
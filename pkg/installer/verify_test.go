@@ -0,0 +1,58 @@
+package installer
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/wellplayedgames/unity-installer/pkg/release"
+)
+
+var _ = Describe("packageVerifier", func() {
+	content := []byte("0123456789abcdef")
+
+	verify := func(pkg *release.Package, data []byte) error {
+		v := newPackageVerifier(pkg)
+		_, err := v.Write(data)
+		Expect(err).NotTo(HaveOccurred())
+		return v.Verify()
+	}
+
+	It("should check download sizes even when there is a checksum", func() {
+		sum := md5.Sum(content)
+		pkg := &release.Package{DownloadSize: release.Size(len(content) + 1)}
+		pkg.Checksum = hex.EncodeToString(sum[:])
+
+		var mismatch *ChecksumMismatchError
+		Expect(errors.As(verify(pkg, content), &mismatch)).To(BeTrue())
+		Expect(mismatch.Algorithm).To(Equal("size"))
+	})
+
+	It("should detect checksum mismatches", func() {
+		md5Sum := md5.Sum(content)
+		sha256Sum := sha256.Sum256(content)
+		pkg := &release.Package{DownloadSize: release.Size(len(content))}
+		pkg.Checksum = hex.EncodeToString(md5Sum[:])
+		pkg.SHA256 = hex.EncodeToString(sha256Sum[:])
+		Expect(verify(pkg, content)).To(Succeed())
+
+		var mismatch *ChecksumMismatchError
+		Expect(errors.As(verify(pkg, []byte("0123456789abcdeF")), &mismatch)).To(BeTrue())
+		Expect(mismatch.Algorithm).To(Equal("md5"))
+
+		pkg.Checksum = ""
+		Expect(errors.As(verify(pkg, []byte("0123456789abcdeF")), &mismatch)).To(BeTrue())
+		Expect(mismatch.Algorithm).To(Equal("sha256"))
+
+		Expect(errors.As(verify(pkg, content[:8]), &mismatch)).To(BeTrue())
+		Expect(mismatch.Algorithm).To(Equal("size"))
+	})
+
+	It("should not check approximate download sizes", func() {
+		pkg := &release.Package{DownloadSize: 1024, ApproximateDownloadSize: true}
+		Expect(verify(pkg, content)).To(Succeed())
+	})
+})
@@ -10,11 +10,13 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
 	shellquote "github.com/kballard/go-shellquote"
+	"github.com/wellplayedgames/unity-installer/pkg/progress"
 	"github.com/wellplayedgames/unity-installer/pkg/release"
 )
 
@@ -27,8 +29,12 @@ const (
 type PackageInstaller interface {
 	io.Closer
 
-	InstallPackage(packagePath string, destination string, options release.InstallOptions) error
+	// InstallPackage installs a package into an editor directory. If
+	// manifest is not nil, the files installed are added to it and it is
+	// stored in the editor directory.
+	InstallPackage(packagePath string, destination string, options release.InstallOptions, manifest *Manifest) error
 	StoreModules(destination string, modules []release.ModuleRelease) error
+	RemovePath(path string) error
 }
 
 func mergeDirectory(src, dest string) error {
@@ -74,20 +80,33 @@ func mergeDirectory(src, dest string) error {
 }
 
 type localInstaller struct{
-	logger logr.Logger
-	dryRun bool
+	logger   logr.Logger
+	dryRun   bool
+	observer progress.Observer
 }
 
-func NewLocalInstaller(logger logr.Logger, dryRun bool) PackageInstaller {
-	return &localInstaller{logger, dryRun}
+// NewLocalInstaller creates a package installer which installs packages in
+// the current process. The observer may be nil.
+func NewLocalInstaller(logger logr.Logger, dryRun bool, observer progress.Observer) PackageInstaller {
+	return &localInstaller{logger, dryRun, observer}
 }
 
 func (i *localInstaller) Close() error {
 	return nil
 }
 
-func (i *localInstaller) StoreModules(destination string, modules []release.ModuleRelease) error {
+func (i *localInstaller) StoreModules(destination string, modules []release.ModuleRelease) (err error) {
 	path := filepath.Join(destination, ModulesFile)
+	tracker := progress.Start(i.observer, path, progress.PhaseStoreModules, 0)
+	defer func() {
+		tracker.Finish(err)
+	}()
+
+	if i.dryRun {
+		i.logger.Info("Dry run, store modules", "path", path)
+		return nil
+	}
+
 	b, err := json.MarshalIndent(&modules, "", "  ")
 	if err != nil {
 		return err
@@ -96,8 +115,24 @@ func (i *localInstaller) StoreModules(destination string, modules []release.Modu
 	return ioutil.WriteFile(path, b, os.ModePerm)
 }
 
+// RemovePath deletes an installed file or directory.
+func (i *localInstaller) RemovePath(path string) (err error) {
+	tracker := progress.Start(i.observer, path, progress.PhaseRemove, 0)
+	defer func() {
+		tracker.Finish(err)
+	}()
+
+	if i.dryRun {
+		i.logger.Info("Dry run, remove", "path", path)
+		return nil
+	}
+
+	i.logger.Info("removing", "path", path)
+	return os.RemoveAll(path)
+}
+
 // InstallPackage installs a single Unity package.
-func (i *localInstaller) InstallPackage(packagePath string, destination string, options release.InstallOptions) error {
+func (i *localInstaller) InstallPackage(packagePath string, destination string, options release.InstallOptions, manifest *Manifest) error {
 	unityPath := destination
 	startTime := time.Now()
 	i.logger.Info("Installing package", "packagePath", packagePath)
@@ -112,22 +147,46 @@ func (i *localInstaller) InstallPackage(packagePath string, destination string,
 		}
 	}
 
+	var before treeSnapshot
+	if !i.dryRun && manifest != nil {
+		var err error
+		if before, err = snapshotTree(unityPath, destination); err != nil {
+			return fmt.Errorf("failed to scan destination: %w", err)
+		}
+	}
+
+	tracker := progress.Start(i.observer, packagePath, progress.PhaseExtract, 0)
 	err := func() error {
 		if strings.HasSuffix(packagePath, ".zip") {
-			return i.installZip(packagePath, destination)
+			return i.installZip(packagePath, destination, tracker)
 		}
 
 		if strings.HasSuffix(packagePath, ".pkg") {
 			return i.installPkg(packagePath, destination)
 		}
 
+		if strings.HasSuffix(packagePath, ".tar.xz") || strings.HasSuffix(packagePath, ".txz") {
+			return i.installTarXz(packagePath, destination)
+		}
+
 		return i.installExe(packagePath, destination, options)
 	}()
+	tracker.Finish(err)
 
 	if err != nil {
 		return err
 	}
 
+	// Find the installed files before they are moved.
+	var installed []string
+	if before != nil {
+		after, err := snapshotTree(unityPath, destination)
+		if err != nil {
+			return fmt.Errorf("failed to scan destination: %w", err)
+		}
+		installed = after.changedSince(before)
+	}
+
 	if !i.dryRun && options.RenameFrom != nil && options.RenameTo != nil {
 		renameFrom := filepath.Clean(strings.ReplaceAll(*options.RenameFrom, "{UNITY_PATH}", unityPath))
 		renameTo := filepath.Clean(strings.ReplaceAll(*options.RenameTo, "{UNITY_PATH}", unityPath))
@@ -148,6 +207,7 @@ func (i *localInstaller) InstallPackage(packagePath string, destination string,
 			return fmt.Errorf("failed to make target directory: %w", err)
 		}
 
+		tracker := progress.Start(i.observer, packagePath, progress.PhaseRename, 0)
 		if !statFrom.IsDir() || destNotExist {
 			i.logger.Info("renaming", "from", renameFrom, "to", renameTo)
 			err = os.Rename(renameFrom, renameTo)
@@ -155,10 +215,24 @@ func (i *localInstaller) InstallPackage(packagePath string, destination string,
 			i.logger.Info("merging directories", "from", renameFrom, "to", renameTo)
 			err = mergeDirectory(renameFrom, renameTo)
 		}
+		tracker.Finish(err)
 
 		if err != nil {
 			return err
 		}
+
+		if installed != nil {
+			installed, err = renameInstalled(installed, unityPath, renameFrom, renameTo)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if before != nil {
+		if err := i.storeManifest(unityPath, *manifest, packagePath, installed); err != nil {
+			return fmt.Errorf("failed to store manifest: %w", err)
+		}
 	}
 
 	endTime := time.Now()
@@ -167,7 +241,71 @@ func (i *localInstaller) InstallPackage(packagePath string, destination string,
 	return nil
 }
 
-func (i *localInstaller) installZip(packagePath string, destination string) error {
+// renameInstalled applies a RenameFrom/RenameTo move to installed paths.
+func renameInstalled(installed []string, unityPath, renameFrom, renameTo string) ([]string, error) {
+	from, err := filepath.Rel(unityPath, renameFrom)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := filepath.Rel(unityPath, renameTo)
+	if err != nil {
+		return nil, err
+	}
+
+	renamed := make([]string, len(installed))
+	for idx, path := range installed {
+		renamed[idx] = renamePath(path, filepath.ToSlash(from), filepath.ToSlash(to))
+	}
+
+	return renamed, nil
+}
+
+// storeManifest writes the manifest of a package from the files it changed.
+// When a package is reinstalled, files which it rewrote with their original
+// modification times look unchanged, so the files of the previous manifest
+// which are still present are kept.
+func (i *localInstaller) storeManifest(unityPath string, manifest Manifest, packagePath string, installed []string) error {
+	if manifest.Package == "" {
+		manifest.Package = filepath.Base(packagePath)
+	}
+	manifest.Files = make([]ManifestFile, 0, len(installed))
+
+	isInstalled := map[string]bool{}
+	for _, path := range installed {
+		entry, err := DescribeFile(unityPath, path)
+		if err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, entry)
+		isInstalled[path] = true
+	}
+
+	previous, err := ReadManifest(unityPath, manifest.Name)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read previous manifest: %w", err)
+	}
+
+	if previous != nil {
+		for _, f := range previous.Files {
+			if isInstalled[f.Path] {
+				continue
+			}
+
+			if _, err := os.Lstat(filepath.Join(unityPath, filepath.FromSlash(f.Path))); err == nil {
+				manifest.Files = append(manifest.Files, f)
+			}
+		}
+	}
+
+	sort.Slice(manifest.Files, func(a, b int) bool {
+		return manifest.Files[a].Path < manifest.Files[b].Path
+	})
+
+	return writeManifest(unityPath, &manifest)
+}
+
+func (i *localInstaller) installZip(packagePath string, destination string, tracker *progress.Tracker) error {
 	if i.dryRun {
 		i.logger.Info("Dry run, extract zip",
 			"packagePath", packagePath,
@@ -185,6 +323,12 @@ func (i *localInstaller) installZip(packagePath string, destination string) erro
 		}
 	}()
 
+	var total int64
+	for _, f := range r.File {
+		total += int64(f.UncompressedSize64)
+	}
+	tracker.SetTotal(total)
+
 	for _, f := range r.File {
 		fr, err := f.Open()
 		if err != nil {
@@ -219,7 +363,7 @@ func (i *localInstaller) installZip(packagePath string, destination string) erro
 					i.logger.Error(err, "failed to close archive")
 				}
 			}()
-			_, err := io.Copy(fw, fr)
+			_, err := io.Copy(io.MultiWriter(fw, tracker), fr)
 			return err
 		}()
 		if err != nil {
@@ -287,6 +431,20 @@ func (i *localInstaller) installPkg(packagePath, destination string) error {
 	return err
 }
 
+func (i *localInstaller) installTarXz(packagePath, destination string) error {
+	if i.dryRun {
+		i.logger.Info("Dry run, extract tarball",
+			"packagePath", packagePath,
+			"destination", destination)
+		return nil
+	}
+
+	cmd := exec.Command("tar", "-C", destination, "-Jmxf", packagePath)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
 func (i *localInstaller) installExe(packagePath string, destination string, options release.InstallOptions) error {
 	var args []string
 	var err error
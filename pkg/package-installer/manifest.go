@@ -0,0 +1,297 @@
+package packageinstaller
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/wellplayedgames/unity-installer/pkg/release"
+)
+
+const (
+	// ManifestsDir is the directory, next to ModulesFile, which holds the
+	// manifest of each installed package.
+	ManifestsDir = "manifests"
+
+	// EditorManifest is the name of the editor package's manifest. Modules'
+	// manifests are named by module ID.
+	EditorManifest = "editor"
+)
+
+// ManifestFile is a file installed by a package.
+type ManifestFile struct {
+	// Path is relative to the editor directory and uses forward slashes.
+	Path   string      `json:"path"`
+	Size   int64       `json:"size"`
+	Mode   os.FileMode `json:"mode"`
+	SHA256 string      `json:"sha256,omitempty"`
+
+	// CRC32 is set instead of SHA256 for manifests built from a zip
+	// package's directory.
+	CRC32 uint32 `json:"crc32,omitempty"`
+
+	// Link is the target of a symbolic link.
+	Link string `json:"link,omitempty"`
+}
+
+// Manifest lists the files installed by a package.
+type Manifest struct {
+	Name    string `json:"name"`
+	Package string `json:"package"`
+
+	// Version, Revision and Architecture describe the editor, and are only
+	// set in its manifest.
+	Version      string `json:"version,omitempty"`
+	Revision     string `json:"revision,omitempty"`
+	Architecture string `json:"architecture,omitempty"`
+
+	Files []ManifestFile `json:"files"`
+}
+
+// ManifestPath returns the path of a package's manifest in an editor
+// directory.
+func ManifestPath(editorDir, name string) string {
+	return filepath.Join(editorDir, ManifestsDir, name+".json")
+}
+
+// ReadManifest reads the manifest of a package installed into an editor
+// directory. If the package has no manifest, the error satisfies
+// os.IsNotExist.
+func ReadManifest(editorDir, name string) (*Manifest, error) {
+	b, err := ioutil.ReadFile(ManifestPath(editorDir, name))
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{}
+	if err := json.Unmarshal(b, manifest); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// ReadManifests reads every package manifest in an editor directory, keyed by
+// name.
+func ReadManifests(editorDir string) (map[string]*Manifest, error) {
+	entries, err := ioutil.ReadDir(filepath.Join(editorDir, ManifestsDir))
+	if os.IsNotExist(err) {
+		return map[string]*Manifest{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	manifests := map[string]*Manifest{}
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".json")
+		if entry.IsDir() || name == entry.Name() {
+			continue
+		}
+
+		manifest, err := ReadManifest(editorDir, name)
+		if err != nil {
+			return nil, err
+		}
+		manifests[name] = manifest
+	}
+
+	return manifests, nil
+}
+
+func writeManifest(editorDir string, manifest *Manifest) error {
+	path := ManifestPath(editorDir, manifest.Name)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+// ZipManifest builds a manifest for a zip package from its directory, for
+// packages which were installed without recording one. Modes are not
+// recorded since they depend on how the package was extracted.
+func ZipManifest(name, zipPath, editorDir string, options release.InstallOptions) (*Manifest, error) {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	destination := editorDir
+	if options.Destination != nil {
+		destination = filepath.Clean(strings.ReplaceAll(*options.Destination, "{UNITY_PATH}", editorDir))
+	}
+
+	var paths []string
+	files := map[string]*zip.File{}
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		rel, err := filepath.Rel(editorDir, filepath.Join(destination, f.Name))
+		if err != nil {
+			return nil, err
+		}
+		rel = filepath.ToSlash(rel)
+
+		paths = append(paths, rel)
+		files[rel] = f
+	}
+
+	renamed := paths
+	if options.RenameFrom != nil && options.RenameTo != nil {
+		renameFrom := filepath.Clean(strings.ReplaceAll(*options.RenameFrom, "{UNITY_PATH}", editorDir))
+		renameTo := filepath.Clean(strings.ReplaceAll(*options.RenameTo, "{UNITY_PATH}", editorDir))
+		if renamed, err = renameInstalled(paths, editorDir, renameFrom, renameTo); err != nil {
+			return nil, err
+		}
+	}
+
+	manifest := &Manifest{
+		Name:    name,
+		Package: filepath.Base(zipPath),
+		Files:   make([]ManifestFile, len(paths)),
+	}
+
+	for idx, path := range paths {
+		f := files[path]
+		manifest.Files[idx] = ManifestFile{
+			Path:  renamed[idx],
+			Size:  int64(f.UncompressedSize64),
+			CRC32: f.CRC32,
+		}
+	}
+
+	sort.Slice(manifest.Files, func(a, b int) bool {
+		return manifest.Files[a].Path < manifest.Files[b].Path
+	})
+
+	return manifest, nil
+}
+
+// HashFile returns the hex encoded SHA-256 of a file.
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// DescribeFile returns the manifest entry of the file at relPath in an editor
+// directory.
+func DescribeFile(editorDir, relPath string) (ManifestFile, error) {
+	path := filepath.Join(editorDir, filepath.FromSlash(relPath))
+	entry := ManifestFile{Path: relPath}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return entry, err
+	}
+	entry.Mode = info.Mode()
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		entry.Link, err = os.Readlink(path)
+		return entry, err
+	}
+
+	entry.Size = info.Size()
+	entry.SHA256, err = HashFile(path)
+	return entry, err
+}
+
+// treeSnapshot records the modification time of every file in a directory
+// tree, so that the files a package installs can be found afterwards.
+type treeSnapshot map[string]int64
+
+// IsStatePath returns true for the installer's own state files, which
+// never belong to a package.
+func IsStatePath(relPath string) bool {
+	return relPath == ModulesFile || relPath == ManifestsDir || strings.HasPrefix(relPath, ManifestsDir+"/")
+}
+
+// snapshotTree walks dir and returns the files in it, keyed by their path
+// relative to base.
+func snapshotTree(base, dir string) (treeSnapshot, error) {
+	snapshot := treeSnapshot{}
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(base, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if IsStatePath(rel) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !info.IsDir() {
+			snapshot[rel] = info.ModTime().UnixNano()
+		}
+
+		return nil
+	})
+
+	return snapshot, err
+}
+
+// changedSince returns the files which are new or modified since before.
+func (s treeSnapshot) changedSince(before treeSnapshot) []string {
+	var changed []string
+
+	for path, modTime := range s {
+		if prev, ok := before[path]; !ok || prev != modTime {
+			changed = append(changed, path)
+		}
+	}
+
+	sort.Strings(changed)
+	return changed
+}
+
+// renamePath applies a RenameFrom/RenameTo move to a relative path.
+func renamePath(relPath, from, to string) string {
+	if relPath == from {
+		return to
+	}
+
+	if from == "." {
+		return strings.TrimPrefix(to+"/"+relPath, "./")
+	}
+
+	if strings.HasPrefix(relPath, from+"/") {
+		return strings.TrimPrefix(to+relPath[len(from):], "./")
+	}
+
+	return relPath
+}
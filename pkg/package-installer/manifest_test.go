@@ -0,0 +1,157 @@
+package packageinstaller
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/go-logr/stdr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/wellplayedgames/unity-installer/pkg/release"
+)
+
+var _ = Describe("Manifest", func() {
+	var (
+		tempDir   string
+		editorDir string
+		installer PackageInstaller
+	)
+
+	writeZip := func(name string, files map[string]string) string {
+		path := filepath.Join(tempDir, name)
+		f, err := os.Create(path)
+		Expect(err).NotTo(HaveOccurred())
+		defer f.Close()
+
+		w := zip.NewWriter(f)
+		for name, content := range files {
+			fw, err := w.Create(name)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = fw.Write([]byte(content))
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(w.Close()).To(Succeed())
+
+		return path
+	}
+
+	stringPtr := func(s string) *string {
+		return &s
+	}
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "manifest-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		editorDir = filepath.Join(tempDir, "2019.4.9f1")
+		installer = NewLocalInstaller(stdr.New(log.New(ioutil.Discard, "", 0)), false, nil)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tempDir)).To(Succeed())
+	})
+
+	It("should record installed files after renames", func() {
+		editorZip := writeZip("editor.zip", map[string]string{
+			"Unity/Unity.app/Contents/Info.plist": "plist",
+			"Unity/Documentation/index.html":      "docs",
+		})
+
+		options := release.InstallOptions{
+			RenameFrom: stringPtr("{UNITY_PATH}/Unity"),
+			RenameTo:   stringPtr("{UNITY_PATH}"),
+		}
+		Expect(installer.InstallPackage(editorZip, editorDir, options, &Manifest{Name: EditorManifest, Version: "2019.4.9f1"})).To(Succeed())
+
+		manifest, err := ReadManifest(editorDir, EditorManifest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manifest.Package).To(Equal("editor.zip"))
+		Expect(manifest.Version).To(Equal("2019.4.9f1"))
+		Expect(manifest.Files).To(HaveLen(2))
+		Expect(manifest.Files[0].Path).To(Equal("Documentation/index.html"))
+		Expect(manifest.Files[1].Path).To(Equal("Unity.app/Contents/Info.plist"))
+
+		sum := sha256.Sum256([]byte("plist"))
+		Expect(manifest.Files[1].SHA256).To(Equal(hex.EncodeToString(sum[:])))
+		Expect(manifest.Files[1].Size).To(Equal(int64(5)))
+		Expect(manifest.Files[1].Mode.IsRegular()).To(BeTrue())
+	})
+
+	It("should only record the files of each package", func() {
+		Expect(installer.InstallPackage(writeZip("editor.zip", map[string]string{
+			"Editor/Unity": "editor",
+		}), editorDir, release.InstallOptions{}, &Manifest{Name: EditorManifest})).To(Succeed())
+		Expect(installer.StoreModules(editorDir, nil)).To(Succeed())
+
+		options := release.InstallOptions{
+			Destination: stringPtr("{UNITY_PATH}/Editor/Data/PlaybackEngines/WebGLSupport"),
+		}
+		Expect(installer.InstallPackage(writeZip("webgl.zip", map[string]string{
+			"ivy.xml": "webgl",
+		}), editorDir, options, &Manifest{Name: "webgl"})).To(Succeed())
+
+		manifests, err := ReadManifests(editorDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manifests).To(HaveLen(2))
+		Expect(manifests["webgl"].Files).To(HaveLen(1))
+		Expect(manifests["webgl"].Files[0].Path).To(Equal("Editor/Data/PlaybackEngines/WebGLSupport/ivy.xml"))
+		Expect(manifests[EditorManifest].Files).To(HaveLen(1))
+	})
+
+	It("should keep the files of the previous manifest which a reinstall left unchanged", func() {
+		options := release.InstallOptions{
+			Destination: stringPtr("{UNITY_PATH}/Editor/Data/PlaybackEngines/WebGLSupport"),
+		}
+		webglZip := writeZip("webgl.zip", map[string]string{
+			"ivy.xml":       "webgl",
+			"Variations.js": "variations",
+		})
+		Expect(installer.InstallPackage(webglZip, editorDir, options, &Manifest{Name: "webgl"})).To(Succeed())
+
+		previous, err := ReadManifest(editorDir, "webgl")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(previous.Files).To(HaveLen(2))
+
+		// Only ivy.xml looks changed, as if the installer had kept
+		// Variations.js's modification time.
+		Expect(installer.(*localInstaller).storeManifest(editorDir, Manifest{Name: "webgl"}, webglZip, []string{
+			"Editor/Data/PlaybackEngines/WebGLSupport/ivy.xml",
+		})).To(Succeed())
+
+		manifest, err := ReadManifest(editorDir, "webgl")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manifest.Files).To(Equal(previous.Files))
+	})
+
+	It("should drop files of the previous manifest which are gone", func() {
+		options := release.InstallOptions{
+			Destination: stringPtr("{UNITY_PATH}/Editor/Data/PlaybackEngines/WebGLSupport"),
+		}
+		Expect(installer.InstallPackage(writeZip("webgl.zip", map[string]string{
+			"ivy.xml":       "webgl",
+			"Variations.js": "variations",
+		}), editorDir, options, &Manifest{Name: "webgl"})).To(Succeed())
+		Expect(os.Remove(filepath.Join(editorDir, "Editor/Data/PlaybackEngines/WebGLSupport/Variations.js"))).To(Succeed())
+
+		Expect(installer.InstallPackage(writeZip("webgl-2.zip", map[string]string{
+			"ivy.xml": "webgl 2",
+		}), editorDir, options, &Manifest{Name: "webgl"})).To(Succeed())
+
+		manifest, err := ReadManifest(editorDir, "webgl")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manifest.Package).To(Equal("webgl-2.zip"))
+		Expect(manifest.Files).To(HaveLen(1))
+		Expect(manifest.Files[0].Path).To(Equal("Editor/Data/PlaybackEngines/WebGLSupport/ivy.xml"))
+	})
+
+	It("should report missing manifests", func() {
+		_, err := ReadManifest(editorDir, "android")
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+})
@@ -4,10 +4,11 @@ package packageinstaller
 
 import (
 	"github.com/go-logr/logr"
+	"github.com/wellplayedgames/unity-installer/pkg/progress"
 )
 
-func NewDefaultInstaller(logger logr.Logger, dryRun bool) (PackageInstaller, error) {
-	return NewLocalInstaller(logger, dryRun), nil
+func NewDefaultInstaller(logger logr.Logger, dryRun bool, observer progress.Observer) (PackageInstaller, error) {
+	return NewLocalInstaller(logger, dryRun, observer), nil
 }
 
 func MaybeHandleService(logger logr.Logger) {}
@@ -16,6 +16,7 @@ import (
 
 	"github.com/Microsoft/go-winio"
 	"github.com/google/uuid"
+	"github.com/wellplayedgames/unity-installer/pkg/progress"
 	"github.com/wellplayedgames/unity-installer/pkg/release"
 )
 
@@ -96,6 +97,8 @@ type installerMessage struct {
 	PackagePath string                  `json:"packagePath"`
 	Destination string                  `json:"destination"`
 	Modules     []release.ModuleRelease `json:"modules"`
+	RemovePath  string                  `json:"removePath,omitempty"`
+	Manifest    *Manifest               `json:"manifest,omitempty"`
 	Options     release.InstallOptions  `json:",inline"`
 }
 
@@ -161,9 +164,10 @@ func writeMessages(w io.Writer, messages <-chan interface{}) error {
 type serviceInstaller struct {
 	requestChannel  chan<- installerMessage
 	responseChannel <-chan responseMessage
+	observer        progress.Observer
 }
 
-func NewServiceInstaller(logger logr.Logger, dryRun bool) (PackageInstaller, error) {
+func NewServiceInstaller(logger logr.Logger, dryRun bool, observer progress.Observer) (PackageInstaller, error) {
 	pipeName := fmt.Sprintf(`\\.\pipe\UnityInstaller-%s`, uuid.New().String())
 
 	l, err := winio.ListenPipe(pipeName, nil)
@@ -220,7 +224,7 @@ func NewServiceInstaller(logger logr.Logger, dryRun bool) (PackageInstaller, err
 		}
 	}()
 
-	return &serviceInstaller{reqCh, respCh}, nil
+	return &serviceInstaller{reqCh, respCh, observer}, nil
 }
 
 func MaybeHandleService(logger logr.Logger) {
@@ -237,7 +241,7 @@ func MaybeHandleService(logger logr.Logger) {
 	logger.Info("starting installer service")
 	pipeName := arg[len(serviceFlag):]
 	dryRun := len(os.Args) >= 3 && os.Args[2] == "--dry-run"
-	inst := NewLocalInstaller(logger, dryRun)
+	inst := NewLocalInstaller(logger, dryRun, nil)
 
 	c, err := winio.DialPipe(pipeName, nil)
 	if err != nil {
@@ -290,8 +294,8 @@ func MaybeHandleService(logger logr.Logger) {
 	handleInstaller(inst, reqCh, respCh)
 }
 
-func NewDefaultInstaller(logger logr.Logger, dryRun bool) (PackageInstaller, error) {
-	return NewServiceInstaller(logger, dryRun)
+func NewDefaultInstaller(logger logr.Logger, dryRun bool, observer progress.Observer) (PackageInstaller, error) {
+	return NewServiceInstaller(logger, dryRun, observer)
 }
 
 func (i *serviceInstaller) Close() error {
@@ -300,7 +304,12 @@ func (i *serviceInstaller) Close() error {
 }
 
 // InstallPackage installs a single Unity package.
-func (i *serviceInstaller) StoreModules(destination string, modules []release.ModuleRelease) error {
+func (i *serviceInstaller) StoreModules(destination string, modules []release.ModuleRelease) (err error) {
+	tracker := progress.Start(i.observer, destination, progress.PhaseStoreModules, 0)
+	defer func() {
+		tracker.Finish(err)
+	}()
+
 	if modules == nil {
 		modules = []release.ModuleRelease{}
 	}
@@ -323,13 +332,44 @@ func (i *serviceInstaller) StoreModules(destination string, modules []release.Mo
 }
 
 // InstallPackage installs a single Unity package.
-func (i *serviceInstaller) InstallPackage(packagePath string, destination string, options release.InstallOptions) error {
+func (i *serviceInstaller) InstallPackage(packagePath string, destination string, options release.InstallOptions, manifest *Manifest) (err error) {
 	fmt.Printf("installing %s...\n", packagePath)
 
+	// The service installs out of process, so report the whole install as
+	// extraction.
+	tracker := progress.Start(i.observer, packagePath, progress.PhaseExtract, 0)
+	defer func() {
+		tracker.Finish(err)
+	}()
+
 	req := installerMessage{
 		PackagePath: packagePath,
 		Destination: destination,
 		Options:     options,
+		Manifest:    manifest,
+	}
+
+	i.requestChannel <- req
+	resp, ok := <-i.responseChannel
+
+	if resp.ErrorString != "" {
+		return errors.New(resp.ErrorString)
+	} else if !ok {
+		return io.ErrUnexpectedEOF
+	}
+
+	return nil
+}
+
+// RemovePath deletes an installed file or directory.
+func (i *serviceInstaller) RemovePath(path string) (err error) {
+	tracker := progress.Start(i.observer, path, progress.PhaseRemove, 0)
+	defer func() {
+		tracker.Finish(err)
+	}()
+
+	req := installerMessage{
+		RemovePath: path,
 	}
 
 	i.requestChannel <- req
@@ -350,10 +390,12 @@ func handleInstaller(inst PackageInstaller, requestChannel <-chan installerMessa
 	for req := range requestChannel {
 		var err error
 
-		if req.Modules != nil {
+		if req.RemovePath != "" {
+			err = inst.RemovePath(req.RemovePath)
+		} else if req.Modules != nil {
 			err = inst.StoreModules(req.Destination, req.Modules)
 		} else {
-			err = inst.InstallPackage(req.PackagePath, req.Destination, req.Options)
+			err = inst.InstallPackage(req.PackagePath, req.Destination, req.Options, req.Manifest)
 		}
 
 		resp := responseMessage{}
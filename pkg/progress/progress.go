@@ -0,0 +1,155 @@
+// Package progress defines the events reported whilst downloading and
+// installing packages, so that callers can display or forward them.
+package progress
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	reportInterval = 250 * time.Millisecond
+)
+
+// Phase is a stage in fetching and installing a package.
+type Phase string
+
+const (
+	// PhaseDownload is fetching a package over the network.
+	PhaseDownload Phase = "download"
+	// PhaseExtract is unpacking or running a package installer.
+	PhaseExtract Phase = "extract"
+	// PhaseRename is moving installed files into their final location.
+	PhaseRename Phase = "rename"
+	// PhaseStoreModules is recording the installed module state.
+	PhaseStoreModules Phase = "store-modules"
+	// PhaseRemove is deleting an installed editor or module.
+	PhaseRemove Phase = "remove"
+)
+
+// Event reports the state of one phase of one package.
+type Event struct {
+	Package string
+	Phase   Phase
+
+	// BytesDone and BytesTotal report progress where known. BytesTotal is
+	// zero if the size is unknown.
+	BytesDone  int64
+	BytesTotal int64
+
+	// Duration is the time since the phase started.
+	Duration time.Duration
+
+	// Finished is set on the last event of a phase, with Err set if the phase
+	// failed.
+	Finished bool
+	Err      error
+}
+
+// Observer receives progress events. Observers may be called from multiple
+// goroutines at once.
+type Observer interface {
+	Observe(event Event)
+}
+
+// ObserverFunc adapts a function to the Observer interface.
+type ObserverFunc func(event Event)
+
+// Observe implements the Observer interface.
+func (f ObserverFunc) Observe(event Event) {
+	f(event)
+}
+
+// Tracker reports the events for a single phase. It implements io.Writer so
+// that data can be counted as it is copied.
+type Tracker struct {
+	observer Observer
+	event    Event
+	start    time.Time
+	last     time.Time
+	lock     sync.Mutex
+}
+
+// Start reports that a phase has started and returns a tracker for it. The
+// observer may be nil, in which case nothing is reported.
+func Start(observer Observer, pkg string, phase Phase, total int64) *Tracker {
+	t := &Tracker{
+		observer: observer,
+		event: Event{
+			Package:    pkg,
+			Phase:      phase,
+			BytesTotal: total,
+		},
+		start: time.Now(),
+	}
+
+	t.report(true)
+	return t
+}
+
+func (t *Tracker) report(force bool) {
+	if t.observer == nil {
+		return
+	}
+
+	now := time.Now()
+	if !force && now.Sub(t.last) < reportInterval {
+		return
+	}
+
+	t.last = now
+	e := t.event
+	e.Duration = now.Sub(t.start)
+	t.observer.Observe(e)
+}
+
+// SetTotal updates the total number of bytes expected.
+func (t *Tracker) SetTotal(total int64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.event.BytesTotal = total
+}
+
+// SetDone updates the number of bytes processed so far, for example when a
+// download is resumed.
+func (t *Tracker) SetDone(done int64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.event.BytesDone = done
+	t.report(false)
+}
+
+// Write counts bytes processed.
+func (t *Tracker) Write(p []byte) (int, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.event.BytesDone += int64(len(p))
+	t.report(false)
+	return len(p), nil
+}
+
+// Finish reports that the phase has completed, successfully if err is nil.
+func (t *Tracker) Finish(err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.event.Finished = true
+	t.event.Err = err
+	t.report(true)
+}
+
+// FormatBytes formats a number of bytes for display, such as "1.5 GiB".
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,113 @@
+package progress
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+type recordingObserver struct {
+	events []Event
+	lock   sync.Mutex
+}
+
+func (o *recordingObserver) Observe(event Event) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.events = append(o.events, event)
+}
+
+var _ = Describe("Tracker", func() {
+	var observer *recordingObserver
+
+	BeforeEach(func() {
+		observer = &recordingObserver{}
+	})
+
+	It("should report the start and end of a phase", func() {
+		tracker := Start(observer, "Unity", PhaseDownload, 10)
+
+		n, err := io.Copy(tracker, strings.NewReader("0123456789"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(int64(10)))
+
+		tracker.Finish(nil)
+
+		Expect(len(observer.events)).To(BeNumerically(">=", 2))
+
+		first := observer.events[0]
+		Expect(first.Package).To(Equal("Unity"))
+		Expect(first.Phase).To(Equal(PhaseDownload))
+		Expect(first.BytesDone).To(BeZero())
+		Expect(first.BytesTotal).To(Equal(int64(10)))
+		Expect(first.Finished).To(BeFalse())
+
+		last := observer.events[len(observer.events)-1]
+		Expect(last.BytesDone).To(Equal(int64(10)))
+		Expect(last.Finished).To(BeTrue())
+		Expect(last.Err).NotTo(HaveOccurred())
+	})
+
+	It("should report failures and updated totals", func() {
+		tracker := Start(observer, "Android", PhaseExtract, 0)
+		tracker.SetTotal(100)
+		tracker.SetDone(40)
+
+		failure := errors.New("extraction failed")
+		tracker.Finish(failure)
+
+		last := observer.events[len(observer.events)-1]
+		Expect(last.Package).To(Equal("Android"))
+		Expect(last.Phase).To(Equal(PhaseExtract))
+		Expect(last.BytesDone).To(Equal(int64(40)))
+		Expect(last.BytesTotal).To(Equal(int64(100)))
+		Expect(last.Finished).To(BeTrue())
+		Expect(last.Err).To(Equal(failure))
+	})
+
+	It("should rate limit intermediate events", func() {
+		tracker := Start(observer, "Unity", PhaseDownload, 0)
+		for i := 0; i < 1000; i++ {
+			_, _ = tracker.Write([]byte{0})
+		}
+		tracker.Finish(nil)
+
+		Expect(len(observer.events)).To(BeNumerically("<", 10))
+		Expect(observer.events[len(observer.events)-1].BytesDone).To(Equal(int64(1000)))
+	})
+
+	It("should allow a nil observer", func() {
+		tracker := Start(nil, "Unity", PhaseDownload, 0)
+		_, err := tracker.Write([]byte("data"))
+		Expect(err).NotTo(HaveOccurred())
+		tracker.Finish(nil)
+	})
+})
+
+var _ = Describe("ObserverFunc", func() {
+	It("should forward events to the function", func() {
+		var got Event
+		Start(ObserverFunc(func(event Event) { got = event }), "Unity", PhaseRemove, 0)
+		Expect(got.Package).To(Equal("Unity"))
+		Expect(got.Phase).To(Equal(PhaseRemove))
+	})
+})
+
+var _ = Describe("FormatBytes", func() {
+	DescribeTable("should format sizes",
+		func(n int64, expected string) {
+			Expect(FormatBytes(n)).To(Equal(expected))
+		},
+		Entry("zero", int64(0), "0 B"),
+		Entry("bytes", int64(1023), "1023 B"),
+		Entry("kibibytes", int64(1024), "1.0 KiB"),
+		Entry("fractional mebibytes", int64(1536*1024), "1.5 MiB"),
+		Entry("gibibytes", int64(3*1024*1024*1024), "3.0 GiB"),
+		Entry("tebibytes", int64(1024*1024*1024*1024), "1.0 TiB"),
+	)
+})
@@ -0,0 +1,312 @@
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/wellplayedgames/unity-installer/pkg/editor"
+	"github.com/wellplayedgames/unity-installer/pkg/retry"
+)
+
+const (
+	defaultReleaseAPIEndpoint = "https://services.api.unity.com/unity/editor/release/v1/releases"
+	defaultReleaseAPIPageSize = 25
+)
+
+// DefaultAPIReleaseSource fetches releases from the Unity Release API.
+var DefaultAPIReleaseSource = APIReleaseSource{
+	HTTPClient:   http.DefaultClient,
+	Endpoint:     defaultReleaseAPIEndpoint,
+	Architecture: ArchX86_64,
+	PageSize:     defaultReleaseAPIPageSize,
+	Retry:        retry.DefaultPolicy,
+}
+
+var _ Source = (*APIReleaseSource)(nil)
+
+type apiSize struct {
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit"`
+}
+
+type apiEULA struct {
+	URL     string `json:"url"`
+	Label   string `json:"label"`
+	Message string `json:"message"`
+}
+
+type apiPathRename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type apiModule struct {
+	ID                  string         `json:"id"`
+	Name                string         `json:"name"`
+	Description         string         `json:"description"`
+	Category            string         `json:"category"`
+	URL                 string         `json:"url"`
+	Integrity           string         `json:"integrity"`
+	Type                string         `json:"type"`
+	DownloadSize        apiSize        `json:"downloadSize"`
+	InstalledSize       apiSize        `json:"installedSize"`
+	Hidden              bool           `json:"hidden"`
+	PreSelected         bool           `json:"preSelected"`
+	Destination         string         `json:"destination"`
+	ExtractedPathRename *apiPathRename `json:"extractedPathRename"`
+	EULA                []apiEULA      `json:"eula"`
+	SubModules          []apiModule    `json:"subModules"`
+}
+
+type apiDownload struct {
+	URL           string      `json:"url"`
+	Integrity     string      `json:"integrity"`
+	Type          string      `json:"type"`
+	Platform      string      `json:"platform"`
+	Architecture  string      `json:"architecture"`
+	DownloadSize  apiSize     `json:"downloadSize"`
+	InstalledSize apiSize     `json:"installedSize"`
+	Modules       []apiModule `json:"modules"`
+}
+
+type apiRelease struct {
+	Version       string        `json:"version"`
+	ShortRevision string        `json:"shortRevision"`
+	Stream        string        `json:"stream"`
+	Downloads     []apiDownload `json:"downloads"`
+}
+
+type apiReleasesPage struct {
+	Offset  int          `json:"offset"`
+	Limit   int          `json:"limit"`
+	Total   int          `json:"total"`
+	Results []apiRelease `json:"results"`
+}
+
+// APIReleaseSource fetches releases from the paginated Unity Release API,
+// which unlike the Hub endpoints covers every release and includes
+// integrity hashes and module hierarchies.
+type APIReleaseSource struct {
+	HTTPClient *http.Client
+	Endpoint   string
+
+	// Architecture is the editor architecture to fetch, one of the Arch
+	// constants.
+	Architecture string
+	PageSize     int
+	Retry        retry.Policy
+}
+
+func apiPlatform(platform string) string {
+	switch platform {
+	case "win32":
+		return "WINDOWS"
+	case "darwin":
+		return "MAC_OS"
+	default:
+		return strings.ToUpper(platform)
+	}
+}
+
+// bytes converts an API size to a number of bytes.
+func (s apiSize) bytes() int64 {
+	switch strings.ToUpper(s.Unit) {
+	case "KILOBYTE":
+		return int64(s.Value * 1024)
+	case "MEGABYTE":
+		return int64(s.Value * 1024 * 1024)
+	case "GIGABYTE":
+		return int64(s.Value * 1024 * 1024 * 1024)
+	default:
+		return int64(s.Value)
+	}
+}
+
+// exact returns true if an API size is in bytes. Sizes in larger units are
+// rounded.
+func (s apiSize) exact() bool {
+	unit := strings.ToUpper(s.Unit)
+	return unit == "" || unit == "BYTE"
+}
+
+// fetchAll fetches every page of releases matching query.
+func (s *APIReleaseSource) fetchAll(ctx context.Context, platform string, query url.Values) ([]apiRelease, error) {
+	pageSize := s.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultReleaseAPIPageSize
+	}
+
+	query.Set("platform", apiPlatform(platform))
+	query.Set("architecture", strings.ToUpper(s.Architecture))
+	query.Set("limit", strconv.Itoa(pageSize))
+
+	var releases []apiRelease
+
+	for offset := 0; ; {
+		query.Set("offset", strconv.Itoa(offset))
+		pageURL := fmt.Sprintf("%s?%s", s.Endpoint, query.Encode())
+
+		var page apiReleasesPage
+		_, err := conditionalGet(ctx, s.HTTPClient, s.Retry, pageURL, Validators{}, func(r io.Reader) error {
+			page = apiReleasesPage{}
+			return json.NewDecoder(r).Decode(&page)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch releases: %w", err)
+		}
+
+		releases = append(releases, page.Results...)
+		offset += len(page.Results)
+
+		if len(page.Results) == 0 || offset >= page.Total {
+			return releases, nil
+		}
+	}
+}
+
+func hydrateAPIModules(platform, parent string, src []apiModule, dest []ModuleRelease) []ModuleRelease {
+	for idx := range src {
+		m := &src[idx]
+
+		module := ModuleRelease{
+			Package: Package{
+				DownloadURL:   m.URL,
+				DownloadSize:  Size(m.DownloadSize.bytes()),
+				InstalledSize: Size(m.InstalledSize.bytes()),
+
+				ApproximateDownloadSize: !m.DownloadSize.exact(),
+			},
+			ID:          m.ID,
+			Name:        m.Name,
+			Description: m.Description,
+			Category:    m.Category,
+			Visible:     !m.Hidden,
+			Parent:      parent,
+		}
+		module.Integrity = m.Integrity
+
+		if m.Destination != "" {
+			module.Destination = stringPtr(m.Destination)
+		} else {
+			module.Destination = moduleDestination(platform, m.ID, path.Ext(m.URL))
+		}
+
+		if m.ExtractedPathRename != nil && m.ExtractedPathRename.From != "" {
+			module.RenameFrom = stringPtr(m.ExtractedPathRename.From)
+			module.RenameTo = stringPtr(m.ExtractedPathRename.To)
+		}
+
+		for _, eula := range m.EULA {
+			module.EULAs = append(module.EULAs, EULA{
+				URL:     eula.URL,
+				Label:   eula.Label,
+				Message: eula.Message,
+			})
+		}
+
+		dest = append(dest, module)
+		dest = hydrateAPIModules(platform, m.ID, m.SubModules, dest)
+	}
+
+	return dest
+}
+
+// toEditorRelease maps an API release to an EditorRelease, returning nil if
+// it has no download for the platform and architecture.
+func (s *APIReleaseSource) toEditorRelease(platform string, src *apiRelease) *EditorRelease {
+	for idx := range src.Downloads {
+		d := &src.Downloads[idx]
+		if d.Platform != apiPlatform(platform) || !strings.EqualFold(d.Architecture, s.Architecture) {
+			continue
+		}
+
+		release := &EditorRelease{
+			Package: Package{
+				DownloadURL:   d.URL,
+				DownloadSize:  Size(d.DownloadSize.bytes()),
+				InstalledSize: Size(d.InstalledSize.bytes()),
+
+				ApproximateDownloadSize: !d.DownloadSize.exact(),
+			},
+			Version:      src.Version,
+			Revision:     src.ShortRevision,
+			LTS:          src.Stream == "LTS",
+			Architecture: s.Architecture,
+		}
+		release.Integrity = d.Integrity
+		release.Modules = hydrateAPIModules(platform, "", d.Modules, nil)
+		return release
+	}
+
+	return nil
+}
+
+func isTestingStream(stream string) bool {
+	return stream == "BETA" || stream == "ALPHA"
+}
+
+// FetchReleases implements the Source interface.
+func (s *APIReleaseSource) FetchReleases(ctx context.Context, platform string, includeBeta bool) (Releases, error) {
+	releases, err := s.fetchAll(ctx, platform, url.Values{})
+	if err != nil {
+		return nil, err
+	}
+
+	ret := Releases{}
+
+	for idx := range releases {
+		r := &releases[idx]
+		if !includeBeta && isTestingStream(r.Stream) {
+			continue
+		}
+
+		if release := s.toEditorRelease(platform, r); release != nil {
+			ret[release.Version] = release
+		}
+	}
+
+	return ret, nil
+}
+
+// FetchRelease implements the Source interface.
+func (s *APIReleaseSource) FetchRelease(ctx context.Context, platform, version, revision string) (*EditorRelease, error) {
+	releases, err := s.fetchAll(ctx, platform, url.Values{"version": []string{version}})
+	if err != nil {
+		return nil, err
+	}
+
+	var best *EditorRelease
+
+	for idx := range releases {
+		r := &releases[idx]
+		if !strings.HasPrefix(r.Version, version) || (revision != "" && r.ShortRevision != revision) {
+			continue
+		}
+
+		release := s.toEditorRelease(platform, r)
+		if release == nil {
+			continue
+		}
+
+		if release.Version == version {
+			return release, nil
+		}
+
+		if best == nil || editor.CompareVersions(release.Version, best.Version) > 0 {
+			best = release
+		}
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrNotFound, version, platform)
+	}
+
+	return best, nil
+}
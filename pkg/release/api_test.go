@@ -0,0 +1,98 @@
+package release
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("APIReleaseSource", func() {
+	var (
+		server *httptest.Server
+		source APIReleaseSource
+		pages  int
+	)
+
+	BeforeEach(func() {
+		pages = 0
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			if query.Get("platform") != "LINUX" || query.Get("architecture") != "X86_64" || query.Get("limit") != "2" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			pages++
+			http.ServeFile(w, r, filepath.Join("testdata", "api-releases-"+query.Get("offset")+".json"))
+		}))
+
+		source = DefaultAPIReleaseSource
+		source.Endpoint = server.URL + "/releases"
+		source.PageSize = 2
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should fetch every page of releases", func() {
+		releases, err := source.FetchReleases(context.Background(), "linux", false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pages).To(Equal(2))
+		Expect(releases).To(HaveLen(2))
+		Expect(releases).To(HaveKey("2022.3.9f1"))
+
+		releases, err = source.FetchReleases(context.Background(), "linux", true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(releases).To(HaveKey("2023.1.0b14"))
+	})
+
+	It("should map releases and module hierarchies", func() {
+		release, err := source.FetchRelease(context.Background(), "linux", "2022.3", "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(release.Version).To(Equal("2022.3.10f1"))
+		Expect(release.Revision).To(Equal("ff3792e53c62"))
+		Expect(release.LTS).To(BeTrue())
+		Expect(release.DownloadURL).To(HaveSuffix("LinuxEditorInstaller/Unity-2022.3.10f1.tar.xz"))
+		Expect(release.Integrity).To(HavePrefix("sha384-"))
+		Expect(release.DownloadSize).To(Equal(Size(1617034516)))
+		Expect(release.ApproximateDownloadSize).To(BeFalse())
+		Expect(release.Modules).To(HaveLen(2))
+
+		android := release.FindModule("android")
+		Expect(android).NotTo(BeNil())
+		Expect(android.DownloadSize).To(Equal(Size(650.5 * 1024 * 1024)))
+		Expect(android.ApproximateDownloadSize).To(BeTrue())
+		Expect(*android.Destination).To(Equal("{UNITY_PATH}/Editor/Data/PlaybackEngines/AndroidPlayer"))
+
+		tools := release.FindModule("android-sdk-ndk-tools")
+		Expect(tools).NotTo(BeNil())
+		Expect(tools.Parent).To(Equal("android"))
+		Expect(tools.Visible).To(BeFalse())
+		Expect(*tools.RenameTo).To(HaveSuffix("SDK/tools"))
+		Expect(tools.EULAs).To(HaveLen(1))
+		Expect(tools.EULAs[0].Label).To(Equal("Android SDK and NDK License Terms from Google"))
+	})
+
+	It("should match revisions", func() {
+		release, err := source.FetchRelease(context.Background(), "linux", "2022.3.9f1", "ea401c316338")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(release.Version).To(Equal("2022.3.9f1"))
+
+		_, err = source.FetchRelease(context.Background(), "linux", "2022.3.9f1", "ff3792e53c62")
+		Expect(err).To(MatchError(ErrNotFound))
+	})
+
+	It("should stop when the context is cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := source.FetchReleases(ctx, "linux", false)
+		Expect(err).To(MatchError(context.Canceled))
+		Expect(pages).To(Equal(0))
+	})
+})
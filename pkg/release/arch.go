@@ -0,0 +1,37 @@
+package release
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// Editor architectures.
+const (
+	ArchX86_64 = "x86_64"
+	ArchARM64  = "arm64"
+)
+
+// NormalizeArchitecture converts a Go or Unity architecture name to one of
+// the Arch constants.
+func NormalizeArchitecture(arch string) (string, error) {
+	switch strings.ToLower(arch) {
+	case "amd64", "x86_64", "x64":
+		return ArchX86_64, nil
+	case "arm64", "aarch64":
+		return ArchARM64, nil
+	}
+
+	return "", fmt.Errorf("unsupported architecture %s", arch)
+}
+
+// HostArchitecture returns the architecture of this machine, or x86_64 if
+// it is not supported by Unity.
+func HostArchitecture() string {
+	arch, err := NormalizeArchitecture(runtime.GOARCH)
+	if err != nil {
+		return ArchX86_64
+	}
+
+	return arch
+}
@@ -0,0 +1,55 @@
+package release
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Architecture", func() {
+	It("should normalize Go and Unity names", func() {
+		for _, name := range []string{"amd64", "x86_64", "X64"} {
+			Expect(NormalizeArchitecture(name)).To(Equal(ArchX86_64))
+		}
+
+		Expect(NormalizeArchitecture("aarch64")).To(Equal(ArchARM64))
+
+		_, err := NormalizeArchitecture("386")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should generate Android modules for the editor architecture", func() {
+		findModule := func(release *EditorRelease, id string) *ModuleRelease {
+			for idx := range release.Modules {
+				if release.Modules[idx].ID == id {
+					return &release.Modules[idx]
+				}
+			}
+			return nil
+		}
+
+		release := &EditorRelease{Version: "2019.4.9f1", Architecture: ArchX86_64}
+		generateAndroidModules(release, "linux")
+		Expect(findModule(release, "android-open-jdk").DownloadURL).To(ContainSubstring("/open-jdk-linux-x64/"))
+		Expect(findModule(release, "android-ndk").DownloadURL).To(HaveSuffix("-linux-x86_64.zip"))
+
+		// Apple Silicon runs the x86_64 tools under emulation.
+		release = &EditorRelease{Version: "2019.4.9f1", Architecture: ArchARM64}
+		generateAndroidModules(release, "darwin")
+		Expect(findModule(release, "android-open-jdk").DownloadURL).To(ContainSubstring("/open-jdk-mac-x64/"))
+		Expect(findModule(release, "android-ndk").DownloadURL).To(HaveSuffix("-darwin-x86_64.zip"))
+
+		release = &EditorRelease{Version: "2019.4.9f1", Architecture: ArchARM64}
+		generateAndroidModules(release, "linux")
+		Expect(findModule(release, "android-open-jdk")).To(BeNil())
+	})
+
+	It("should pick native Android tools where they are published", func() {
+		Expect(androidToolArch("darwin", "")).To(Equal(ArchX86_64))
+		Expect(androidToolArch("windows", ArchARM64)).To(Equal(ArchX86_64))
+		Expect(androidToolArch("linux", ArchARM64)).To(Equal(""))
+
+		androidToolBuilds["linux"] = []string{ArchX86_64, ArchARM64}
+		defer func() { androidToolBuilds["linux"] = []string{ArchX86_64} }()
+		Expect(androidToolArch("linux", ArchARM64)).To(Equal(ArchARM64))
+	})
+})
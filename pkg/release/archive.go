@@ -1,14 +1,16 @@
 package release
 
 import (
+	"context"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"net/http"
 	"path"
 	"path/filepath"
 	"strings"
 
 	"github.com/go-ini/ini"
+	"github.com/wellplayedgames/unity-installer/pkg/retry"
 )
 
 const (
@@ -20,38 +22,23 @@ type archiveModule struct {
 	Description   string  `ini:"description"`
 	URL           string  `ini:"url"`
 	MD5           string  `ini:"md5"`
-	InstalledSize int64   `ini:"installedsize"`
-	DownloadSize  int64   `ini:"size"`
+	InstalledSize float64 `ini:"installedsize"`
+	DownloadSize  float64 `ini:"size"`
 	Command       *string `ini:"cmd"`
 }
 
-func fetchIni(c *http.Client, url string) (*ini.File, error) {
-	resp, err := c.Get(url)
-	if err != nil {
-		return nil, err
-	}
-
-	defer func() {
-		if cerr := resp.Body.Close(); err == nil {
-			err = cerr
-		}
-	}()
+func fetchIni(ctx context.Context, c *http.Client, policy retry.Policy, url string, since Validators) (*ini.File, Validators, error) {
+	var file *ini.File
 
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := ioutil.ReadAll(resp.Body)
-		return nil, fmt.Errorf("bad status %d fetching %s: %s", resp.StatusCode, url, string(bodyBytes))
-	}
+	validators, err := conditionalGet(ctx, c, policy, url, since, func(r io.Reader) (err error) {
+		file, err = ini.Load(r)
+		return err
+	})
 
-	file, err := ini.Load(resp.Body)
-	return file, err
+	return file, validators, err
 }
 
-func parseArchive(c *http.Client, archiveURL, platform, version string) (*EditorRelease, error) {
-	meta, err := fetchIni(c, archiveURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to download archive metadata: %w", err)
-	}
-
+func parseArchive(meta *ini.File, archiveURL, platform, arch, version string) (*EditorRelease, error) {
 	baseURL, _ := path.Split(archiveURL)
 	modules := map[string]*archiveModule{}
 	for _, section := range meta.Sections() {
@@ -71,7 +58,8 @@ func parseArchive(c *http.Client, archiveURL, platform, version string) (*Editor
 
 	var release EditorRelease
 	release.Version = version
-	hydratePackage(baseURL, &release.Package, editorModuleName, editorModule)
+	release.Architecture = arch
+	hydratePackage(platform, baseURL, &release.Package, editorModuleName, editorModule)
 
 	for moduleName, src := range modules {
 		var dest ModuleRelease
@@ -83,7 +71,17 @@ func parseArchive(c *http.Client, archiveURL, platform, version string) (*Editor
 	return &release, nil
 }
 
-func hydratePackage(baseURL string, dest *Package, moduleName string, src *archiveModule) {
+// archiveSize converts a size from archive metadata to bytes. Like Unity Hub,
+// this assumes sizes are in bytes on Linux and kilobytes elsewhere.
+func archiveSize(platform string, size float64) Size {
+	if platform == "linux" {
+		return Size(size)
+	}
+
+	return Size(size * 1024)
+}
+
+func hydratePackage(platform, baseURL string, dest *Package, moduleName string, src *archiveModule) {
 	url := src.URL
 	if !strings.Contains(url, "://") {
 		url = joinSlash(baseURL, url)
@@ -92,10 +90,15 @@ func hydratePackage(baseURL string, dest *Package, moduleName string, src *archi
 	dest.Command = moduleCommand(strings.ToLower(moduleName), src)
 	dest.Checksum = src.MD5
 	dest.DownloadURL = url
+
+	// Archive sizes are rounded to kilobytes except on Linux.
+	dest.DownloadSize = archiveSize(platform, src.DownloadSize)
+	dest.ApproximateDownloadSize = platform != "linux"
+	dest.InstalledSize = archiveSize(platform, src.InstalledSize)
 }
 
 func hydrateArchiveModule(platform, baseURL string, dest *ModuleRelease, moduleName string, src *archiveModule) {
-	hydratePackage(baseURL, &dest.Package, moduleName, src)
+	hydratePackage(platform, baseURL, &dest.Package, moduleName, src)
 
 	lowerName := strings.ToLower(moduleName)
 	ext := filepath.Ext(src.URL)
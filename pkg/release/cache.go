@@ -1,6 +1,7 @@
 package release
 
 import (
+	"context"
 	"fmt"
 	"sync"
 )
@@ -24,7 +25,7 @@ func NewCache(inner Source) *Cache {
 }
 
 // FetchReleases implements the Source interface.
-func (c *Cache) FetchReleases(platform string, includeBeta bool) (Releases, error) {
+func (c *Cache) FetchReleases(ctx context.Context, platform string, includeBeta bool) (Releases, error) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
@@ -32,7 +33,7 @@ func (c *Cache) FetchReleases(platform string, includeBeta bool) (Releases, erro
 		return existing, nil
 	}
 
-	releases, err := c.inner.FetchReleases(platform, includeBeta)
+	releases, err := c.inner.FetchReleases(ctx, platform, includeBeta)
 	if err != nil {
 		return nil, err
 	}
@@ -42,7 +43,7 @@ func (c *Cache) FetchReleases(platform string, includeBeta bool) (Releases, erro
 }
 
 // FetchRelease implements the Source interface.
-func (c *Cache) FetchRelease(platform, version, revision string) (*EditorRelease, error) {
+func (c *Cache) FetchRelease(ctx context.Context, platform, version, revision string) (*EditorRelease, error) {
 	key := fmt.Sprintf("%s@%s", version, revision)
 
 	c.lock.Lock()
@@ -52,7 +53,7 @@ func (c *Cache) FetchRelease(platform, version, revision string) (*EditorRelease
 		return existing, nil
 	}
 
-	release, err := c.inner.FetchRelease(platform, version, revision)
+	release, err := c.inner.FetchRelease(ctx, platform, version, revision)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,181 @@
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/wellplayedgames/unity-installer/pkg/retry"
+)
+
+// ErrOffline is returned by a DiskCache in offline mode when the requested
+// metadata has never been cached.
+var ErrOffline = errors.New("release metadata is not available offline")
+
+// DiskCache persists release fetches to disk so that they can be reused
+// between runs. Entries older than the TTL are revalidated against the inner
+// source, using conditional requests if it is a RevalidatingSource. Stale
+// entries are served if the inner source can't be reached, and in offline
+// mode the inner source is never used at all.
+type DiskCache struct {
+	logger  logr.Logger
+	inner   Source
+	dir     string
+	ttl     time.Duration
+	offline bool
+}
+
+var _ Source = (*DiskCache)(nil)
+
+type diskCacheEntry struct {
+	FetchedAt  time.Time      `json:"fetchedAt"`
+	Validators Validators     `json:"validators"`
+	Releases   Releases       `json:"releases,omitempty"`
+	Release    *EditorRelease `json:"release,omitempty"`
+}
+
+// NewDiskCache creates a release source which caches another release source
+// in dir.
+func NewDiskCache(logger logr.Logger, inner Source, dir string, ttl time.Duration, offline bool) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	return &DiskCache{logger, inner, dir, ttl, offline}, nil
+}
+
+func (c *DiskCache) load(entryPath string) (*diskCacheEntry, error) {
+	b, err := ioutil.ReadFile(entryPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	entry := &diskCacheEntry{}
+	if err := json.Unmarshal(b, entry); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+func (c *DiskCache) store(entryPath string, entry *diskCacheEntry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that other processes never read a
+	// partial entry.
+	f, err := ioutil.TempFile(c.dir, filepath.Base(entryPath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), entryPath)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+
+	return err
+}
+
+func (c *DiskCache) fetch(name string, fetch func(since Validators) (*diskCacheEntry, error)) (*diskCacheEntry, error) {
+	entryPath := filepath.Join(c.dir, name)
+
+	cached, err := c.load(entryPath)
+	if err != nil {
+		c.logger.Error(err, "ignoring unreadable cache entry", "path", entryPath)
+		cached = nil
+	}
+
+	if cached != nil && (c.offline || time.Since(cached.FetchedAt) < c.ttl) {
+		return cached, nil
+	}
+
+	if c.offline {
+		return nil, fmt.Errorf("%s: %w", name, ErrOffline)
+	}
+
+	var since Validators
+	if cached != nil {
+		since = cached.Validators
+	}
+
+	entry, err := fetch(since)
+	if cached != nil && errors.Is(err, ErrNotModified) {
+		c.logger.V(1).Info("cached release metadata is still valid", "name", name)
+		entry = cached
+	} else if cached != nil && retry.IsUnreachable(err) {
+		// Leave the entry stale so that the next fetch tries again.
+		c.logger.Error(err, "failed to refresh release metadata, using stale cache entry", "name", name, "fetchedAt", cached.FetchedAt)
+		return cached, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	entry.FetchedAt = time.Now()
+	if err := c.store(entryPath, entry); err != nil {
+		c.logger.Error(err, "failed to store cache entry", "path", entryPath)
+	}
+
+	return entry, nil
+}
+
+// FetchReleases implements the Source interface.
+func (c *DiskCache) FetchReleases(ctx context.Context, platform string, includeBeta bool) (Releases, error) {
+	name := fmt.Sprintf("releases-%s.json", url.QueryEscape(platform))
+	if includeBeta {
+		name = fmt.Sprintf("releases-%s-beta.json", url.QueryEscape(platform))
+	}
+
+	entry, err := c.fetch(name, func(since Validators) (*diskCacheEntry, error) {
+		if rs, ok := c.inner.(RevalidatingSource); ok {
+			releases, validators, err := rs.FetchReleasesIfModified(ctx, platform, includeBeta, since)
+			return &diskCacheEntry{Validators: validators, Releases: releases}, err
+		}
+
+		releases, err := c.inner.FetchReleases(ctx, platform, includeBeta)
+		return &diskCacheEntry{Releases: releases}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entry.Releases, nil
+}
+
+// FetchRelease implements the Source interface.
+func (c *DiskCache) FetchRelease(ctx context.Context, platform, version, revision string) (*EditorRelease, error) {
+	name := fmt.Sprintf("release-%s-%s@%s.json",
+		url.QueryEscape(platform), url.QueryEscape(version), url.QueryEscape(revision))
+
+	entry, err := c.fetch(name, func(since Validators) (*diskCacheEntry, error) {
+		if rs, ok := c.inner.(RevalidatingSource); ok {
+			release, validators, err := rs.FetchReleaseIfModified(ctx, platform, version, revision, since)
+			return &diskCacheEntry{Validators: validators, Release: release}, err
+		}
+
+		release, err := c.inner.FetchRelease(ctx, platform, version, revision)
+		return &diskCacheEntry{Release: release}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entry.Release, nil
+}
@@ -0,0 +1,136 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"time"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/wellplayedgames/unity-installer/pkg/retry"
+)
+
+const testReleasesJSON = `{
+  "official": [{"version": "2019.4.1f1", "downloadUrl": "https://example.com/Unity.pkg", "modules": []}],
+  "beta": []
+}`
+
+type testReleasesServer struct {
+	*httptest.Server
+
+	lock        sync.Mutex
+	requests    int
+	notModified int
+}
+
+func newTestReleasesServer() *testReleasesServer {
+	s := &testReleasesServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func (s *testReleasesServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.requests++
+
+	if r.Header.Get("If-None-Match") == `"v1"` {
+		s.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("ETag", `"v1"`)
+	_, _ = w.Write([]byte(testReleasesJSON))
+}
+
+var _ = Describe("DiskCache", func() {
+	var (
+		tempDir string
+		server  *testReleasesServer
+		source  HTTPReleaseSource
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "release-cache-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		server = newTestReleasesServer()
+		source = DefaultReleaseSource
+		source.PublishedVersionsEndpoint = server.URL
+	})
+
+	AfterEach(func() {
+		server.Close()
+		Expect(os.RemoveAll(tempDir)).To(Succeed())
+	})
+
+	newCache := func(ttl time.Duration, offline bool) *DiskCache {
+		cache, err := NewDiskCache(logrtesting.NullLogger{}, &source, tempDir, ttl, offline)
+		Expect(err).NotTo(HaveOccurred())
+		return cache
+	}
+
+	It("should reuse fresh entries between instances", func() {
+		_, err := newCache(time.Hour, false).FetchReleases(context.Background(), "linux", false)
+		Expect(err).NotTo(HaveOccurred())
+
+		releases, err := newCache(time.Hour, false).FetchReleases(context.Background(), "linux", false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(releases).To(HaveKey("2019.4.1f1"))
+		Expect(server.requests).To(Equal(1))
+	})
+
+	It("should revalidate expired entries", func() {
+		cache := newCache(0, false)
+		_, err := cache.FetchReleases(context.Background(), "linux", false)
+		Expect(err).NotTo(HaveOccurred())
+
+		releases, err := cache.FetchReleases(context.Background(), "linux", false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(releases).To(HaveKey("2019.4.1f1"))
+		Expect(server.requests).To(Equal(2))
+		Expect(server.notModified).To(Equal(1))
+	})
+
+	It("should serve stale entries when the source can't be reached", func() {
+		source.Retry = retry.Policy{Attempts: 1}
+		cache := newCache(0, false)
+		_, err := cache.FetchReleases(context.Background(), "linux", false)
+		Expect(err).NotTo(HaveOccurred())
+		server.Close()
+
+		releases, err := cache.FetchReleases(context.Background(), "linux", false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(releases).To(HaveKey("2019.4.1f1"))
+	})
+
+	It("should not hide errors when nothing is cached", func() {
+		source.Retry = retry.Policy{Attempts: 1}
+		server.Close()
+
+		_, err := newCache(0, false).FetchReleases(context.Background(), "linux", false)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should serve stale entries when offline", func() {
+		_, err := newCache(0, false).FetchRelease(context.Background(), "linux", "2019.4", "")
+		Expect(err).NotTo(HaveOccurred())
+		server.Close()
+
+		offline := newCache(0, true)
+		release, err := offline.FetchRelease(context.Background(), "linux", "2019.4", "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(release.Version).To(Equal("2019.4.1f1"))
+
+		_, err = offline.FetchReleases(context.Background(), "darwin", false)
+		Expect(errors.Is(err, ErrOffline)).To(BeTrue())
+	})
+})
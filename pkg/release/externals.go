@@ -13,6 +13,17 @@ var useModuleCommand = map[string]bool {
 	"facebookgameroom": true,
 }
 
+// builtinModuleDependencies lists the dependencies of modules whose metadata
+// doesn't include them, such as those from the archive or generated here.
+var builtinModuleDependencies = map[string][]string{
+	"android-sdk-build-tools":    {"android"},
+	"android-sdk-platforms":      {"android"},
+	"android-sdk-platform-tools": {"android"},
+	"android-sdk-ndk-tools":      {"android"},
+	"android-ndk":                {"android"},
+	"android-open-jdk":           {"android"},
+}
+
 func stringPtr(s string) *string {
 	return &s
 }
@@ -48,9 +59,26 @@ func moduleCommand(name string, src *archiveModule) *string {
 	return &cmd
 }
 
+// isTarball returns true if ext is the extension of a Linux .tar.xz package.
+func isTarball(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".xz", ".txz", ".tar.xz":
+		return true
+	}
+
+	return false
+}
+
 func moduleDestination(platform, name, ext string) *string {
 	isZip := strings.ToLower(ext) == ".zip"
 
+	// Linux module tarballs hold paths relative to the editor, such as
+	// Editor/Data/PlaybackEngines/AndroidPlayer, so they are always extracted
+	// into the editor directory.
+	if platform == "linux" && isTarball(ext) {
+		return stringPtr("{UNITY_PATH}")
+	}
+
 	switch name {
 	case "mono":
 		fallthrough
@@ -154,14 +182,56 @@ func prepareExternalModule(platform string, m *ModuleRelease) {
 	m.InstallOptions.Destination = moduleDestination(platform, m.ID, ext)
 }
 
+// androidToolBuilds lists the architectures which the Android tools used
+// here are published for on each host.
+var androidToolBuilds = map[string][]string{
+	"windows": {ArchX86_64},
+	"darwin":  {ArchX86_64},
+	"linux":   {ArchX86_64},
+}
+
+// androidToolArch returns the architecture of the Android tools to install
+// for an editor, or "" if there are none it can run. A native build is
+// preferred; otherwise macOS and Windows run x86_64 builds under emulation.
+func androidToolArch(host, arch string) string {
+	if arch == "" {
+		arch = ArchX86_64
+	}
+
+	builds := androidToolBuilds[host]
+	for _, build := range builds {
+		if build == arch {
+			return build
+		}
+	}
+
+	if host != "linux" {
+		for _, build := range builds {
+			if build == ArchX86_64 {
+				return build
+			}
+		}
+	}
+
+	return ""
+}
+
+// generateAndroidModules adds the Android tools to a release from the
+// download archive, built for the release's architecture. If no tools are
+// published which it can run, none are added and modules which need them
+// can't be installed.
 func generateAndroidModules(release *EditorRelease, platform string) {
 	editorVersion := release.Version
-	arch := "x86_64"
 	host := platform
 	if platform == "win32" {
 		host = "windows"
 	}
 
+	arch := androidToolArch(host, release.Architecture)
+	if arch == "" {
+		return
+	}
+
 	var modules []ModuleRelease
 
 	// Add Android platform.
@@ -242,15 +312,15 @@ func generateAndroidModules(release *EditorRelease, platform string) {
 	})
 
 	// Add OpenJDK.
-	downloadURL := ""
+	jdkHost := host
 	switch host {
 	case "windows":
-		downloadURL = "http://download.unity3d.com/download_unity/open-jdk/open-jdk-win-x64/jdk8u172-b11_4be8440cc514099cfe1b50cbc74128f6955cd90fd5afe15ea7be60f832de67b4.zip"
+		jdkHost = "win"
 	case "darwin":
-		downloadURL = "http://download.unity3d.com/download_unity/open-jdk/open-jdk-mac-x64/jdk8u172-b11_4be8440cc514099cfe1b50cbc74128f6955cd90fd5afe15ea7be60f832de67b4.zip"
-	case "linux":
-		downloadURL = "http://download.unity3d.com/download_unity/open-jdk/open-jdk-linux-x64/jdk8u172-b11_4be8440cc514099cfe1b50cbc74128f6955cd90fd5afe15ea7be60f832de67b4.zip"
+		jdkHost = "mac"
 	}
+	jdkArch := strings.Replace(arch, ArchX86_64, "x64", 1)
+	downloadURL := fmt.Sprintf("http://download.unity3d.com/download_unity/open-jdk/open-jdk-%s-%s/jdk8u172-b11_4be8440cc514099cfe1b50cbc74128f6955cd90fd5afe15ea7be60f832de67b4.zip", jdkHost, jdkArch)
 	modules = append(modules, ModuleRelease{
 		ID:   "android-open-jdk",
 		Name: "OpenJDK",
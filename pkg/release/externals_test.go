@@ -0,0 +1,18 @@
+package release
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("moduleDestination", func() {
+	It("should extract Linux module tarballs into the editor directory", func() {
+		Expect(*moduleDestination("linux", "android", ".xz")).To(Equal("{UNITY_PATH}"))
+		Expect(*moduleDestination("linux", "webgl", ".xz")).To(Equal("{UNITY_PATH}"))
+	})
+
+	It("should use the editor data directory for other Linux packages", func() {
+		Expect(*moduleDestination("linux", "android-ndk", ".zip")).To(Equal("{UNITY_PATH}/Editor/Data/PlaybackEngines/AndroidPlayer/NDK"))
+		Expect(*moduleDestination("win32", "android", ".exe")).To(Equal("{UNITY_PATH}/Editor/Data/PlaybackEngines/AndroidPlayer"))
+	})
+})
@@ -0,0 +1,212 @@
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/wellplayedgames/unity-installer/pkg/editor"
+)
+
+// FileReleaseSource reads releases from a directory tree, typically generated
+// from the output of the distill command. The layout is:
+//
+//	<root>/<platform>/<version>/<revision>.json
+//
+// where each JSON file is an EditorRelease. Editors for architectures other
+// than x86_64 are kept in a <platform>-<architecture> directory instead.
+type FileReleaseSource struct {
+	Root         string
+	Architecture string
+}
+
+var _ Source = (*FileReleaseSource)(nil)
+
+// FileURLPath returns the local path of a file:// URL, including Windows
+// drive paths such as file:///C:/Unity and UNC paths such as
+// file://server/share.
+func FileURLPath(rawURL string) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "file" {
+		return "", false
+	}
+
+	p := u.Path
+	if u.Host != "" && u.Host != "localhost" {
+		p = fmt.Sprintf("//%s%s", u.Host, u.Path)
+	}
+
+	// file:///C:/Unity is parsed with a leading slash before the drive.
+	if len(p) > 2 && p[0] == '/' && p[2] == ':' {
+		p = p[1:]
+	}
+
+	return filepath.FromSlash(p), true
+}
+
+// NewFileReleaseSource creates a release source from a directory path or a
+// file:// URL.
+func NewFileReleaseSource(location string) (*FileReleaseSource, error) {
+	root := location
+
+	if strings.Contains(location, "://") {
+		p, ok := FileURLPath(location)
+		if !ok {
+			return nil, fmt.Errorf("unsupported release catalog URL %s: only file:// URLs are supported", location)
+		}
+		root = p
+	}
+
+	if info, err := os.Stat(root); err != nil {
+		return nil, fmt.Errorf("failed to open release catalog: %w", err)
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("release catalog %s is not a directory", root)
+	}
+
+	return &FileReleaseSource{Root: root}, nil
+}
+
+func (s *FileReleaseSource) platformDir(platform string) string {
+	if s.Architecture == "" || s.Architecture == ArchX86_64 {
+		return filepath.Join(s.Root, platform)
+	}
+
+	return filepath.Join(s.Root, fmt.Sprintf("%s-%s", platform, s.Architecture))
+}
+
+func (s *FileReleaseSource) readRelease(path string) (*EditorRelease, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	release := &EditorRelease{}
+	if err := json.Unmarshal(b, release); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if release.Architecture == "" {
+		release.Architecture = s.Architecture
+	}
+
+	return release, nil
+}
+
+// revisions lists the revisions available for a version, sorted so that the
+// choice of revision is stable.
+func (s *FileReleaseSource) revisions(platform, version string) ([]string, error) {
+	infos, err := ioutil.ReadDir(filepath.Join(s.platformDir(platform), version))
+	if err != nil {
+		return nil, err
+	}
+
+	var revisions []string
+	for _, info := range infos {
+		name := info.Name()
+		revision := strings.TrimSuffix(name, ".json")
+		if !info.IsDir() && filepath.Ext(name) == ".json" && editor.IsValidRevision(revision) {
+			revisions = append(revisions, revision)
+		}
+	}
+
+	sort.Strings(revisions)
+	return revisions, nil
+}
+
+// FetchReleases implements the Source interface.
+func (s *FileReleaseSource) FetchReleases(ctx context.Context, platform string, includeBeta bool) (Releases, error) {
+	infos, err := ioutil.ReadDir(s.platformDir(platform))
+	if os.IsNotExist(err) {
+		return Releases{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	ret := Releases{}
+
+	for _, info := range infos {
+		version := info.Name()
+		if !info.IsDir() || !editor.IsValidVersion(version) || (!includeBeta && strings.ContainsAny(version, "ab")) {
+			continue
+		}
+
+		revisions, err := s.revisions(platform, version)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(revisions) == 0 {
+			continue
+		}
+
+		release, err := s.readRelease(filepath.Join(s.platformDir(platform), version, revisions[0]+".json"))
+		if err != nil {
+			return nil, err
+		}
+
+		if release.Revision == "" {
+			release.Revision = revisions[0]
+		}
+
+		ret[version] = release
+	}
+
+	return ret, nil
+}
+
+// FetchRelease implements the Source interface.
+func (s *FileReleaseSource) FetchRelease(ctx context.Context, platform, version, revision string) (*EditorRelease, error) {
+	// Both end up in paths, so make sure they can't escape the catalog.
+	if !editor.IsValidVersion(version) {
+		return nil, fmt.Errorf("invalid version %q", version)
+	}
+
+	if revision != "" && !editor.IsValidRevision(revision) {
+		return nil, fmt.Errorf("invalid revision %q", revision)
+	}
+
+	if revision != "" {
+		path := filepath.Join(s.platformDir(platform), version, revision+".json")
+		release, err := s.readRelease(path)
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s (%s) %s", ErrNotFound, version, revision, platform)
+		} else if err != nil {
+			return nil, err
+		}
+
+		if release.Revision == "" {
+			release.Revision = revision
+		}
+
+		return release, nil
+	}
+
+	releases, err := s.FetchReleases(ctx, platform, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if release, ok := releases[version]; ok {
+		return release, nil
+	}
+
+	var best *EditorRelease
+	bestVersion := ""
+	for v, release := range releases {
+		if strings.HasPrefix(v, version) && (best == nil || editor.CompareVersions(v, bestVersion) > 0) {
+			best, bestVersion = release, v
+		}
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrNotFound, version, platform)
+	}
+
+	return best, nil
+}
@@ -0,0 +1,127 @@
+package release
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FileReleaseSource", func() {
+	var (
+		tempDir string
+		source  *FileReleaseSource
+	)
+
+	writeRelease := func(platform, version, revision string) {
+		dir := filepath.Join(tempDir, platform, version)
+		Expect(os.MkdirAll(dir, os.ModePerm)).To(Succeed())
+
+		content := `{"version": "` + version + `", "downloadUrl": "https://example.com/` + revision + `.pkg", "modules": []}`
+		Expect(ioutil.WriteFile(filepath.Join(dir, revision+".json"), []byte(content), 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "release-file-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		writeRelease("linux", "2019.4.9f1", "50fe8a171dd9")
+		writeRelease("linux", "2019.4.10f1", "5311b3af6f69")
+		writeRelease("linux", "2020.1.0b1", "7f8c4a1e0c4a")
+
+		source, err = NewFileReleaseSource("file://" + filepath.ToSlash(tempDir))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tempDir)).To(Succeed())
+	})
+
+	It("should list releases", func() {
+		releases, err := source.FetchReleases(context.Background(), "linux", false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(releases).To(HaveLen(2))
+		Expect(releases).To(HaveKey("2019.4.10f1"))
+
+		releases, err = source.FetchReleases(context.Background(), "linux", true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(releases).To(HaveKey("2020.1.0b1"))
+	})
+
+	It("should fetch a specific revision", func() {
+		release, err := source.FetchRelease(context.Background(), "linux", "2019.4.9f1", "50fe8a171dd9")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(release.DownloadURL).To(Equal("https://example.com/50fe8a171dd9.pkg"))
+
+		_, err = source.FetchRelease(context.Background(), "linux", "2019.4.9f1", "000000000000")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject versions and revisions which aren't safe to use in paths", func() {
+		writeRelease("linux", "2019.4.9f1", "not-a-revision")
+
+		_, err := source.FetchRelease(context.Background(), "linux", "../..", "secret")
+		Expect(err).To(MatchError(`invalid version "../.."`))
+
+		_, err = source.FetchRelease(context.Background(), "linux", "2019.4.9f1", "../../../secret")
+		Expect(err).To(MatchError(`invalid revision "../../../secret"`))
+
+		_, err = source.FetchRelease(context.Background(), "linux", "2019.4.9f1", "not-a-revision")
+		Expect(err).To(MatchError(`invalid revision "not-a-revision"`))
+	})
+
+	It("should ignore directories and files which aren't versions or revisions", func() {
+		Expect(os.MkdirAll(filepath.Join(tempDir, "linux", "not-a-version"), os.ModePerm)).To(Succeed())
+		writeRelease("linux", "2019.4.11f1", "not-a-revision")
+
+		releases, err := source.FetchReleases(context.Background(), "linux", false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(releases).To(HaveLen(2))
+		Expect(releases).NotTo(HaveKey("not-a-version"))
+		Expect(releases).NotTo(HaveKey("2019.4.11f1"))
+	})
+
+	It("should pick the highest version matching a prefix", func() {
+		release, err := source.FetchRelease(context.Background(), "linux", "2019.4", "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(release.Version).To(Equal("2019.4.10f1"))
+	})
+
+	It("should read other architectures from their own directory", func() {
+		writeRelease("linux-arm64", "2019.4.9f1", "50fe8a171dd9")
+		source.Architecture = ArchARM64
+
+		releases, err := source.FetchReleases(context.Background(), "linux", false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(releases).To(HaveLen(1))
+		Expect(releases["2019.4.9f1"].Architecture).To(Equal(ArchARM64))
+	})
+})
+
+var _ = Describe("FileURLPath", func() {
+	It("should convert file URLs to local paths", func() {
+		p, ok := FileURLPath("file:///srv/unity/catalog")
+		Expect(ok).To(BeTrue())
+		Expect(p).To(Equal(filepath.FromSlash("/srv/unity/catalog")))
+
+		p, ok = FileURLPath("file:///C:/Unity/bundle")
+		Expect(ok).To(BeTrue())
+		Expect(p).To(Equal(filepath.FromSlash("C:/Unity/bundle")))
+
+		p, ok = FileURLPath("file://server/share/catalog")
+		Expect(ok).To(BeTrue())
+		Expect(p).To(Equal(filepath.FromSlash("//server/share/catalog")))
+	})
+
+	It("should reject other URLs", func() {
+		_, ok := FileURLPath("https://example.com/catalog")
+		Expect(ok).To(BeFalse())
+
+		_, ok = FileURLPath("/srv/unity/catalog")
+		Expect(ok).To(BeFalse())
+	})
+})
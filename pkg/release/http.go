@@ -1,10 +1,23 @@
 package release
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"regexp"
 	"strings"
+
+	"github.com/wellplayedgames/unity-installer/pkg/editor"
+	"github.com/wellplayedgames/unity-installer/pkg/retry"
+)
+
+var (
+	_ RevalidatingSource = (*HTTPReleaseSource)(nil)
+
+	revisionURLRegexp = regexp.MustCompile(`/([0-9a-f]{12})/`)
 )
 
 type httpReleases struct {
@@ -19,6 +32,25 @@ type HTTPReleaseSource struct {
 	PublishedVersionsEndpoint string
 	GAArchiveURL              string
 	TestingArchiveURL         string
+	Retry                     retry.Policy
+
+	// Architecture is the editor architecture to fetch. The Hub endpoints
+	// only provide x86_64 editors, so no releases are found for others.
+	Architecture string
+
+	// Revisions is optional and used to find the revision of versions which
+	// are not in the published releases.
+	Revisions RevisionResolver
+}
+
+// revisionFromURL extracts the revision hash from an archive download URL
+// such as https://download.unity3d.com/download_unity/<revision>/...
+func revisionFromURL(downloadURL string) string {
+	if match := revisionURLRegexp.FindStringSubmatch(downloadURL); match != nil {
+		return match[1]
+	}
+
+	return ""
 }
 
 func joinSlash(a, b string) string {
@@ -36,34 +68,70 @@ func joinSlash(a, b string) string {
 	return a + b[1:]
 }
 
-func (s *HTTPReleaseSource) fetch(platform string) (*httpReleases, error) {
-	url := joinSlash(s.PublishedVersionsEndpoint, fmt.Sprintf("releases-%s.json", platform))
-	resp, err := s.HTTPClient.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		if nextErr := resp.Body.Close(); err == nil {
-			err = nextErr
+// conditionalGet fetches url and passes the response body to decode. If since
+// is non-empty and the server reports the resource as unchanged,
+// ErrNotModified is returned instead.
+func conditionalGet(ctx context.Context, c *http.Client, policy retry.Policy, url string, since Validators, decode func(r io.Reader) error) (Validators, error) {
+	var validators Validators
+
+	err := policy.Do(ctx, func() (err error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
 		}
-	}()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error fetching releases: %d", resp.StatusCode)
-	}
+		if since.ETag != "" {
+			req.Header.Set("If-None-Match", since.ETag)
+		}
+		if since.LastModified != "" {
+			req.Header.Set("If-Modified-Since", since.LastModified)
+		}
+
+		resp, err := c.Do(req)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if nextErr := resp.Body.Close(); err == nil {
+				err = nextErr
+			}
+		}()
+
+		if resp.StatusCode == http.StatusNotModified {
+			return ErrNotModified
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			return retry.NewStatusError(resp)
+		}
 
-	d := json.NewDecoder(resp.Body)
+		validators = Validators{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		}
+		return decode(resp.Body)
+	})
+
+	return validators, err
+}
 
+func (s *HTTPReleaseSource) fetch(ctx context.Context, platform string, since Validators) (*httpReleases, Validators, error) {
+	url := joinSlash(s.PublishedVersionsEndpoint, fmt.Sprintf("releases-%s.json", platform))
 	releases := &httpReleases{}
-	err = d.Decode(releases)
+
+	validators, err := conditionalGet(ctx, s.HTTPClient, s.Retry, url, since, func(r io.Reader) error {
+		*releases = httpReleases{}
+		d := json.NewDecoder(r)
+		return d.Decode(releases)
+	})
 	if err != nil {
-		return nil, err
+		return nil, Validators{}, err
 	}
 
-	return releases, nil
+	return releases, validators, nil
 }
 
-func (s *HTTPReleaseSource) fetchSpecificRelease(baseURL, platform, version, revision string) (*EditorRelease, error) {
+func (s *HTTPReleaseSource) fetchSpecificRelease(ctx context.Context, baseURL, platform, version, revision string, since Validators) (*EditorRelease, Validators, error) {
 	suffix := platform
 	if platform == "win32" {
 		suffix = "win"
@@ -72,55 +140,110 @@ func (s *HTTPReleaseSource) fetchSpecificRelease(baseURL, platform, version, rev
 	}
 
 	url := joinSlash(baseURL, fmt.Sprintf("%s/unity-%s-%s.ini", revision, version, suffix))
-	editorRelease, err := parseArchive(s.HTTPClient, url, platform, version)
+	meta, validators, err := fetchIni(ctx, s.HTTPClient, s.Retry, url, since)
+	if errors.Is(err, ErrNotModified) {
+		return nil, Validators{}, err
+	} else if err != nil {
+		return nil, Validators{}, fmt.Errorf("failed to download archive metadata: %w", err)
+	}
+
+	editorRelease, err := parseArchive(meta, url, platform, ArchX86_64, version)
 	if err != nil {
-		return nil, err
+		return nil, Validators{}, err
 	}
 
-	return editorRelease, nil
+	editorRelease.Revision = revision
+
+	return editorRelease, validators, nil
+}
+
+func (s *HTTPReleaseSource) supportsArchitecture() bool {
+	return s.Architecture == "" || s.Architecture == ArchX86_64
 }
 
 // FetchReleases implements the Source interface.
-func (s *HTTPReleaseSource) FetchReleases(platform string, includeBeta bool) (Releases, error) {
-	releases, err := s.fetch(platform)
+func (s *HTTPReleaseSource) FetchReleases(ctx context.Context, platform string, includeBeta bool) (Releases, error) {
+	releases, _, err := s.FetchReleasesIfModified(ctx, platform, includeBeta, Validators{})
+	return releases, err
+}
+
+// FetchReleasesIfModified implements the RevalidatingSource interface.
+func (s *HTTPReleaseSource) FetchReleasesIfModified(ctx context.Context, platform string, includeBeta bool, since Validators) (Releases, Validators, error) {
+	if !s.supportsArchitecture() {
+		return Releases{}, Validators{}, nil
+	}
+
+	releases, validators, err := s.fetch(ctx, platform, since)
 	if err != nil {
-		return nil, err
+		return nil, Validators{}, err
 	}
 
 	ret := Releases{}
 
 	for idx := range releases.Official {
 		v := &releases.Official[idx]
+		v.Revision = revisionFromURL(v.DownloadURL)
+		v.Architecture = ArchX86_64
 		ret[v.Version] = v
 	}
 
 	if includeBeta {
 		for idx := range releases.Beta {
 			v := &releases.Beta[idx]
+			v.Revision = revisionFromURL(v.DownloadURL)
+			v.Architecture = ArchX86_64
 			ret[v.Version] = v
 		}
 	}
 
-	return ret, nil
+	return ret, validators, nil
 }
 
 // FetchRelease implements the Source interface.
-func (s *HTTPReleaseSource) FetchRelease(platform, version, revision string) (*EditorRelease, error) {
+func (s *HTTPReleaseSource) FetchRelease(ctx context.Context, platform, version, revision string) (*EditorRelease, error) {
+	release, _, err := s.FetchReleaseIfModified(ctx, platform, version, revision, Validators{})
+	return release, err
+}
+
+// FetchReleaseIfModified implements the RevalidatingSource interface.
+func (s *HTTPReleaseSource) FetchReleaseIfModified(ctx context.Context, platform, version, revision string, since Validators) (*EditorRelease, Validators, error) {
+	if !s.supportsArchitecture() {
+		return nil, Validators{}, fmt.Errorf("%w: %s %s (%s editors are not in the Unity Hub releases)", ErrNotFound, version, platform, s.Architecture)
+	}
+
 	isTesting := strings.ContainsAny(version, "ab")
 
 	if revision == "" {
-		releases, err := s.FetchReleases(platform, isTesting)
+		releases, validators, err := s.FetchReleasesIfModified(ctx, platform, isTesting, since)
 		if err != nil {
-			return nil, err
+			return nil, Validators{}, err
+		}
+
+		if release, ok := releases[version]; ok {
+			return release, validators, nil
 		}
 
-		for _, release := range releases {
-			if strings.HasPrefix(release.Version, version) {
-				return release, nil
+		var best *EditorRelease
+		for v, release := range releases {
+			if strings.HasPrefix(v, version) && (best == nil || editor.CompareVersions(v, best.Version) > 0) {
+				best = release
 			}
 		}
 
-		return nil, fmt.Errorf("no such version: %s %s", version, platform)
+		if best != nil {
+			return best, validators, nil
+		}
+
+		if s.Revisions == nil {
+			return nil, Validators{}, fmt.Errorf("%w: %s %s", ErrNotFound, version, platform)
+		}
+
+		// Older versions are not published, but their archive metadata can
+		// still be fetched if the revision is known.
+		revision, err = s.Revisions.ResolveRevision(ctx, version)
+		if err != nil {
+			return nil, Validators{}, err
+		}
 	}
 
 	baseUrl := s.GAArchiveURL
@@ -128,5 +251,5 @@ func (s *HTTPReleaseSource) FetchRelease(platform, version, revision string) (*E
 		baseUrl = s.TestingArchiveURL
 	}
 
-	return s.fetchSpecificRelease(baseUrl, platform, version, revision)
+	return s.fetchSpecificRelease(ctx, baseUrl, platform, version, revision, since)
 }
@@ -0,0 +1,128 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/go-logr/logr"
+	"github.com/wellplayedgames/unity-installer/pkg/retry"
+)
+
+// NamedSource is a Source with a name to identify it in logs and specs.
+type NamedSource struct {
+	Name   string
+	Source Source
+}
+
+// MultiSource tries several release sources in priority order.
+//
+// FetchReleases merges the releases of every source. If more than one source
+// has the same version, the earliest source wins. FetchRelease returns the
+// release from the first source which has it. In both cases a source which
+// does not have the release or cannot be reached is skipped, but any other
+// error is returned immediately.
+//
+// Releases returned by a MultiSource have Source set to the name of the
+// source which provided them.
+type MultiSource struct {
+	logger  logr.Logger
+	sources []NamedSource
+}
+
+var _ Source = (*MultiSource)(nil)
+
+// NewMultiSource creates a release source which tries sources in order.
+func NewMultiSource(logger logr.Logger, sources ...NamedSource) *MultiSource {
+	return &MultiSource{logger, sources}
+}
+
+// shouldFallBack returns true if err means that the next source should be
+// tried.
+func shouldFallBack(err error) bool {
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrOffline) || os.IsNotExist(err) || retry.IsUnreachable(err) {
+		return true
+	}
+
+	var statusErr *retry.StatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusNotFound, http.StatusForbidden, http.StatusGone:
+			return true
+		}
+	}
+
+	return false
+}
+
+func withSource(release *EditorRelease, name string) *EditorRelease {
+	r := *release
+	r.Source = name
+	return &r
+}
+
+// FetchReleases implements the Source interface.
+func (s *MultiSource) FetchReleases(ctx context.Context, platform string, includeBeta bool) (Releases, error) {
+	ret := Releases{}
+	var firstErr error
+	succeeded := false
+
+	for _, source := range s.sources {
+		releases, err := source.Source.FetchReleases(ctx, platform, includeBeta)
+		if err != nil {
+			if !shouldFallBack(err) {
+				return nil, fmt.Errorf("%s: %w", source.Name, err)
+			}
+
+			s.logger.Error(err, "skipping release source", "source", source.Name)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", source.Name, err)
+			}
+			continue
+		}
+
+		succeeded = true
+		for version, release := range releases {
+			if _, ok := ret[version]; !ok {
+				ret[version] = withSource(release, source.Name)
+			}
+		}
+	}
+
+	if !succeeded && firstErr != nil {
+		return nil, firstErr
+	}
+
+	return ret, nil
+}
+
+// FetchRelease implements the Source interface.
+func (s *MultiSource) FetchRelease(ctx context.Context, platform, version, revision string) (*EditorRelease, error) {
+	var firstErr error
+
+	for _, source := range s.sources {
+		release, err := source.Source.FetchRelease(ctx, platform, version, revision)
+		if err != nil {
+			if !shouldFallBack(err) {
+				return nil, fmt.Errorf("%s: %w", source.Name, err)
+			}
+
+			s.logger.V(1).Info("release source could not provide release", "source", source.Name, "version", version, "error", err.Error())
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		s.logger.Info("found release", "source", source.Name, "version", release.Version)
+		return withSource(release, source.Name), nil
+	}
+
+	if firstErr == nil {
+		firstErr = fmt.Errorf("%w: %s %s", ErrNotFound, version, platform)
+	}
+
+	return nil, firstErr
+}
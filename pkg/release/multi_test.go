@@ -0,0 +1,93 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type testSource struct {
+	releases Releases
+	err      error
+}
+
+func (s *testSource) FetchReleases(ctx context.Context, platform string, includeBeta bool) (Releases, error) {
+	return s.releases, s.err
+}
+
+func (s *testSource) FetchRelease(ctx context.Context, platform, version, revision string) (*EditorRelease, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	if r, ok := s.releases[version]; ok {
+		return r, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s %s", ErrNotFound, version, platform)
+}
+
+var _ = Describe("MultiSource", func() {
+	var (
+		mirror   *testSource
+		official *testSource
+		source   *MultiSource
+	)
+
+	BeforeEach(func() {
+		mirror = &testSource{releases: Releases{
+			"2019.4.9f1": {Version: "2019.4.9f1", Package: Package{DownloadURL: "https://mirror/9.pkg"}},
+		}}
+		official = &testSource{releases: Releases{
+			"2019.4.9f1":  {Version: "2019.4.9f1", Package: Package{DownloadURL: "https://unity/9.pkg"}},
+			"2019.4.10f1": {Version: "2019.4.10f1", Package: Package{DownloadURL: "https://unity/10.pkg"}},
+		}}
+		source = NewMultiSource(logrtesting.NullLogger{},
+			NamedSource{"mirror", mirror},
+			NamedSource{"unity", official})
+	})
+
+	It("should merge releases with earlier sources taking precedence", func() {
+		releases, err := source.FetchReleases(context.Background(), "linux", false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(releases).To(HaveLen(2))
+		Expect(releases["2019.4.9f1"].Source).To(Equal("mirror"))
+		Expect(releases["2019.4.9f1"].DownloadURL).To(Equal("https://mirror/9.pkg"))
+		Expect(releases["2019.4.10f1"].Source).To(Equal("unity"))
+	})
+
+	It("should fall back when a release is not found", func() {
+		release, err := source.FetchRelease(context.Background(), "linux", "2019.4.10f1", "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(release.Source).To(Equal("unity"))
+		Expect(official.releases["2019.4.10f1"].Source).To(BeEmpty())
+	})
+
+	It("should fall back when a source is offline", func() {
+		mirror.err = ErrOffline
+
+		release, err := source.FetchRelease(context.Background(), "linux", "2019.4.9f1", "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(release.Source).To(Equal("unity"))
+
+		releases, err := source.FetchReleases(context.Background(), "linux", false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(releases).To(HaveLen(2))
+	})
+
+	It("should not fall back on other errors", func() {
+		mirror.err = errors.New("corrupt metadata")
+
+		_, err := source.FetchRelease(context.Background(), "linux", "2019.4.9f1", "")
+		Expect(err).To(MatchError(ContainSubstring("mirror: corrupt metadata")))
+	})
+
+	It("should report not found when no source has a release", func() {
+		_, err := source.FetchRelease(context.Background(), "linux", "2020.1.0f1", "")
+		Expect(errors.Is(err, ErrNotFound)).To(BeTrue())
+	})
+})
@@ -1,7 +1,12 @@
 package release
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
+
+	"github.com/wellplayedgames/unity-installer/pkg/retry"
 )
 
 var (
@@ -15,14 +20,39 @@ var (
 		PublishedVersionsEndpoint: defaultPublishedReleasesEndpoint,
 		GAArchiveURL:              defaultGAArchiveEndpoint,
 		TestingArchiveURL:         defaultTestingArchiveEndpoint,
+		Retry:                     retry.DefaultPolicy,
 	}
 )
 
 // Source provides a means of listing released Unity versions with
 // metadata.
 type Source interface {
-	FetchReleases(platform string, includeBeta bool) (Releases, error)
-	FetchRelease(platform, version, revision string) (*EditorRelease, error)
+	FetchReleases(ctx context.Context, platform string, includeBeta bool) (Releases, error)
+	FetchRelease(ctx context.Context, platform, version, revision string) (*EditorRelease, error)
+}
+
+// ErrNotFound is returned by a Source when the requested release does not
+// exist.
+var ErrNotFound = errors.New("no such version")
+
+// ErrNotModified is returned by a RevalidatingSource when the requested
+// metadata has not changed since it was last fetched.
+var ErrNotModified = errors.New("release metadata not modified")
+
+// Validators identify a fetched version of release metadata so that it can be
+// revalidated later with a conditional request.
+type Validators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// RevalidatingSource is a Source which can skip fetching metadata which has
+// not changed. If since is non-empty and the metadata is unchanged,
+// ErrNotModified is returned.
+type RevalidatingSource interface {
+	Source
+	FetchReleasesIfModified(ctx context.Context, platform string, includeBeta bool, since Validators) (Releases, Validators, error)
+	FetchReleaseIfModified(ctx context.Context, platform, version, revision string, since Validators) (*EditorRelease, Validators, error)
 }
 
 // InstallOptions provides the options to configure a package to install.
@@ -34,6 +64,8 @@ type InstallOptions struct {
 	RenameFrom *string `json:"renameFrom"`
 	RenameTo   *string `json:"renameTo"`
 	Checksum   string  `json:"checksum,omitempty"`
+	SHA256     string  `json:"sha256,omitempty"`
+	Integrity  string  `json:"integrity,omitempty"`
 }
 
 // Package represents a single package which will be installed as part of a
@@ -42,10 +74,32 @@ type Package struct {
 	InstallOptions `json:",inline"`
 	Version        string `json:"version"`
 	DownloadURL    string `json:"downloadUrl"`
-	DownloadSize   int64  `json:"downloadSize"`
-	// This has been removed as it was found to contain floats when installed
-	// from UnityHub which causes it to fail
-	// InstalledSize  int64  `json:"installedSize"`
+	DownloadSize   Size   `json:"downloadSize"`
+	InstalledSize  Size   `json:"installedSize,omitempty"`
+
+	// ApproximateDownloadSize is set when DownloadSize has been rounded by
+	// the source, in which case it is only used for estimates and downloads
+	// aren't checked against it.
+	ApproximateDownloadSize bool `json:"approximateDownloadSize,omitempty"`
+}
+
+// Size is a number of bytes. Unity Hub sometimes writes sizes as floats, so
+// they are accepted and truncated when decoding.
+type Size int64
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (s *Size) UnmarshalJSON(b []byte) error {
+	var f *float64
+	if err := json.Unmarshal(b, &f); err != nil {
+		return err
+	}
+
+	*s = 0
+	if f != nil {
+		*s = Size(*f)
+	}
+
+	return nil
 }
 
 // ModuleRelease represents an optional Unity module tied to a specific editor
@@ -58,14 +112,61 @@ type ModuleRelease struct {
 	Category    string `json:"category,omitempty"`
 	Visible     bool   `json:"visible"`
 	Selected    bool   `json:"selected"`
+	EULAs       []EULA `json:"eulas,omitempty"`
+
+	// Parent is the ID of the module this is a sub-module of, if any.
+	Parent string `json:"parent,omitempty"`
+	// Sync is the ID of a module which this is installed alongside, as
+	// written by Unity Hub.
+	Sync string `json:"sync,omitempty"`
+	// Dependencies lists the IDs of any other modules which must be
+	// installed first.
+	Dependencies []string `json:"dependencies,omitempty"`
+}
+
+// Requires returns the IDs of the modules which must be installed before this
+// one. If the module does not record any, they are looked up in a built-in
+// table of known modules.
+func (m *ModuleRelease) Requires() []string {
+	var ids []string
+	seen := map[string]bool{m.ID: true}
+
+	for _, id := range append([]string{m.Parent, m.Sync}, m.Dependencies...) {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) == 0 {
+		ids = builtinModuleDependencies[m.ID]
+	}
+
+	return ids
+}
+
+// EULA is a licence agreement which must be accepted to install a module.
+type EULA struct {
+	URL     string `json:"url"`
+	Label   string `json:"label,omitempty"`
+	Message string `json:"message,omitempty"`
 }
 
 // EditorRelease represents a single release of a Unity version.
 type EditorRelease struct {
 	Package `json:",inline"`
 
-	Version string `json:"version"`
-	LTS     bool   `json:"lts"`
+	Version  string `json:"version"`
+	Revision string `json:"revision,omitempty"`
+	LTS      bool   `json:"lts"`
+
+	// Architecture is the host architecture the editor is built for, one
+	// of the Arch constants. Empty means x86_64.
+	Architecture string `json:"architecture,omitempty"`
+
+	// Source names the release source which provided this release, if it
+	// was fetched through a MultiSource.
+	Source string `json:"source,omitempty"`
 
 	Modules []ModuleRelease `json:"modules"`
 }
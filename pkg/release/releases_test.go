@@ -0,0 +1,19 @@
+package release
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Package", func() {
+	It("should accept float sizes written by Unity Hub", func() {
+		var pkg Package
+		Expect(json.Unmarshal([]byte(`{"downloadSize": 100, "installedSize": 5.24288e+06}`), &pkg)).To(Succeed())
+		Expect(pkg.InstalledSize).To(Equal(Size(5242880)))
+
+		Expect(json.Unmarshal([]byte(`{"installedSize": null}`), &pkg)).To(Succeed())
+		Expect(pkg.InstalledSize).To(Equal(Size(0)))
+	})
+})
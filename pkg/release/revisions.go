@@ -0,0 +1,193 @@
+package release
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/wellplayedgames/unity-installer/pkg/retry"
+)
+
+// DefaultRevisionIndexURL is the Unity download archive, which links to
+// every release with a unityhub:// link.
+const DefaultRevisionIndexURL = "https://unity.com/releases/editor/archive"
+
+var hubLinkRegexp = regexp.MustCompile(`unityhub://([0-9]+\.[0-9]+\.[0-9]+[abfp][0-9]+)/([0-9a-fA-F]+)`)
+
+// RevisionResolver finds the revision hash of a Unity version.
+type RevisionResolver interface {
+	ResolveRevision(ctx context.Context, version string) (string, error)
+}
+
+// RevisionIndex resolves revisions using an index fetched from a URL or
+// local path. The index is either a JSON object mapping versions to
+// revisions, or any document containing unityhub://<version>/<revision>
+// links, such as the Unity download archive.
+//
+// If CacheFile is set, the index is stored there as JSON and reused until it
+// is older than TTL. A stale cached index is used if the index cannot be
+// fetched.
+type RevisionIndex struct {
+	Logger     logr.Logger
+	HTTPClient *http.Client
+	URL        string
+	CacheFile  string
+	TTL        time.Duration
+	Retry      retry.Policy
+
+	lock  sync.Mutex
+	index map[string]string
+}
+
+var _ RevisionResolver = (*RevisionIndex)(nil)
+
+// parseRevisionIndex parses an index document.
+func parseRevisionIndex(b []byte) (map[string]string, error) {
+	index := map[string]string{}
+
+	if trimmed := bytes.TrimSpace(b); len(trimmed) > 0 && trimmed[0] == '{' {
+		if err := json.Unmarshal(trimmed, &index); err != nil {
+			return nil, fmt.Errorf("failed to parse revision index: %w", err)
+		}
+
+		return index, nil
+	}
+
+	for _, match := range hubLinkRegexp.FindAllSubmatch(b, -1) {
+		index[string(match[1])] = strings.ToLower(string(match[2]))
+	}
+
+	return index, nil
+}
+
+func (r *RevisionIndex) fetch(ctx context.Context) ([]byte, error) {
+	if localPath, ok := localIndexPath(r.URL); ok {
+		return ioutil.ReadFile(localPath)
+	}
+
+	var b []byte
+	_, err := conditionalGet(ctx, r.HTTPClient, r.Retry, r.URL, Validators{}, func(body io.Reader) (err error) {
+		b, err = ioutil.ReadAll(body)
+		return err
+	})
+
+	return b, err
+}
+
+func localIndexPath(location string) (string, bool) {
+	if p, ok := FileURLPath(location); ok {
+		return p, true
+	}
+
+	if !strings.Contains(location, "://") {
+		return location, true
+	}
+
+	return "", false
+}
+
+func (r *RevisionIndex) loadCache() (map[string]string, bool) {
+	info, err := os.Stat(r.CacheFile)
+	if err != nil {
+		return nil, false
+	}
+
+	b, err := ioutil.ReadFile(r.CacheFile)
+	if err != nil {
+		return nil, false
+	}
+
+	index := map[string]string{}
+	if err := json.Unmarshal(b, &index); err != nil {
+		r.Logger.Error(err, "ignoring unreadable revision index cache", "path", r.CacheFile)
+		return nil, false
+	}
+
+	return index, time.Since(info.ModTime()) < r.TTL
+}
+
+func (r *RevisionIndex) storeCache(index map[string]string) error {
+	b, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.CacheFile), os.ModePerm); err != nil {
+		return err
+	}
+
+	tempPath := r.CacheFile + ".tmp"
+	if err := ioutil.WriteFile(tempPath, b, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tempPath, r.CacheFile)
+}
+
+// load returns the index, fetching it if it has not been loaded yet.
+func (r *RevisionIndex) load(ctx context.Context) (map[string]string, error) {
+	if r.index != nil {
+		return r.index, nil
+	}
+
+	var cached map[string]string
+	if r.CacheFile != "" {
+		var fresh bool
+		cached, fresh = r.loadCache()
+		if fresh {
+			r.index = cached
+			return cached, nil
+		}
+	}
+
+	b, err := r.fetch(ctx)
+	if err == nil {
+		r.index, err = parseRevisionIndex(b)
+	}
+	if err != nil {
+		if cached == nil {
+			return nil, fmt.Errorf("failed to load revision index: %w", err)
+		}
+
+		r.Logger.Error(err, "failed to refresh revision index, using cached copy")
+		r.index = cached
+		return cached, nil
+	}
+
+	if r.CacheFile != "" {
+		if err := r.storeCache(r.index); err != nil {
+			r.Logger.Error(err, "failed to cache revision index", "path", r.CacheFile)
+		}
+	}
+
+	return r.index, nil
+}
+
+// ResolveRevision implements the RevisionResolver interface.
+func (r *RevisionIndex) ResolveRevision(ctx context.Context, version string) (string, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	index, err := r.load(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	revision, ok := index[version]
+	if !ok {
+		return "", fmt.Errorf("%w: no revision known for %s", ErrNotFound, version)
+	}
+
+	return revision, nil
+}
@@ -0,0 +1,123 @@
+package release
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const testArchiveHTML = `<html><body>
+<a href="unityhub://2017.4.40f1/6e14067f8a9a">Unity Hub</a>
+<a href="unityhub://2018.4.36f1/6cd387d23174">Unity Hub</a>
+</body></html>`
+
+const testArchiveIni = `[Unity]
+title=Unity 2017.4.40f1
+description=Unity Editor
+url=LinuxEditorInstaller/Unity.tar.xz
+md5=0123456789abcdef0123456789abcdef
+size=100
+installedsize=200
+`
+
+var _ = Describe("RevisionIndex", func() {
+	var (
+		tempDir      string
+		server       *httptest.Server
+		archiveReads int
+		index        *RevisionIndex
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "revision-index-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		archiveReads = 0
+		mux := http.NewServeMux()
+		mux.HandleFunc("/hub/releases-linux.json", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(testReleasesJSON))
+		})
+		mux.HandleFunc("/archive", func(w http.ResponseWriter, r *http.Request) {
+			archiveReads++
+			_, _ = w.Write([]byte(testArchiveHTML))
+		})
+		mux.HandleFunc("/download/6e14067f8a9a/unity-2017.4.40f1-linux.ini", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(testArchiveIni))
+		})
+		server = httptest.NewServer(mux)
+
+		index = &RevisionIndex{
+			Logger:     logrtesting.NullLogger{},
+			HTTPClient: http.DefaultClient,
+			URL:        server.URL + "/archive",
+			CacheFile:  filepath.Join(tempDir, "revisions.json"),
+			TTL:        time.Hour,
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+		Expect(os.RemoveAll(tempDir)).To(Succeed())
+	})
+
+	It("should resolve revisions from the archive listing", func() {
+		revision, err := index.ResolveRevision(context.Background(), "2018.4.36f1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(revision).To(Equal("6cd387d23174"))
+
+		_, err = index.ResolveRevision(context.Background(), "2018.4.99f1")
+		Expect(err).To(MatchError(ErrNotFound))
+	})
+
+	It("should resolve revisions from a JSON index", func() {
+		indexPath := filepath.Join(tempDir, "index.json")
+		Expect(ioutil.WriteFile(indexPath, []byte(`{"2019.1.0f2": "292b93d75a2c"}`), 0644)).To(Succeed())
+		index.URL = indexPath
+
+		revision, err := index.ResolveRevision(context.Background(), "2019.1.0f2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(revision).To(Equal("292b93d75a2c"))
+	})
+
+	It("should reuse the cached index", func() {
+		_, err := index.ResolveRevision(context.Background(), "2017.4.40f1")
+		Expect(err).NotTo(HaveOccurred())
+		server.Close()
+
+		cached := &RevisionIndex{
+			Logger:    logrtesting.NullLogger{},
+			URL:       index.URL,
+			CacheFile: index.CacheFile,
+			TTL:       time.Hour,
+		}
+		revision, err := cached.ResolveRevision(context.Background(), "2017.4.40f1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(revision).To(Equal("6e14067f8a9a"))
+		Expect(archiveReads).To(Equal(1))
+	})
+
+	It("should let HTTPReleaseSource fetch versions missing from the feed", func() {
+		source := DefaultReleaseSource
+		source.PublishedVersionsEndpoint = server.URL + "/hub/"
+		source.GAArchiveURL = server.URL + "/download/"
+		source.Revisions = index
+
+		release, err := source.FetchRelease(context.Background(), "linux", "2017.4.40f1", "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(release.Version).To(Equal("2017.4.40f1"))
+		Expect(release.Revision).To(Equal("6e14067f8a9a"))
+		Expect(release.DownloadURL).To(Equal(server.URL + "/download/6e14067f8a9a/LinuxEditorInstaller/Unity.tar.xz"))
+		Expect(release.DownloadSize).To(Equal(Size(100)))
+		Expect(release.ApproximateDownloadSize).To(BeFalse())
+		Expect(release.InstalledSize).To(Equal(Size(200)))
+	})
+})
@@ -0,0 +1,53 @@
+package release
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wellplayedgames/unity-installer/pkg/editor"
+)
+
+// LatestMatching returns the highest version in releases which is selected
+// by selector, or nil if there is none.
+func LatestMatching(releases Releases, selector *editor.VersionSelector) *EditorRelease {
+	var best *EditorRelease
+	bestVersion := ""
+
+	for version, release := range releases {
+		if !selector.Match(version, release.LTS) {
+			continue
+		}
+
+		if best == nil || editor.CompareVersions(version, bestVersion) > 0 {
+			best, bestVersion = release, version
+		}
+	}
+
+	return best
+}
+
+// SelectRelease finds the release chosen by a version selector. Exact
+// versions are fetched directly, otherwise the highest matching release
+// from the list of releases is used. A revision can only be given with an
+// exact version.
+func SelectRelease(ctx context.Context, source Source, platform string, selector *editor.VersionSelector, revision string) (*EditorRelease, error) {
+	if version, ok := selector.Exact(); ok {
+		return source.FetchRelease(ctx, platform, version, revision)
+	}
+
+	if revision != "" {
+		return nil, fmt.Errorf("a revision can only be given with an exact version")
+	}
+
+	releases, err := source.FetchReleases(ctx, platform, selector.IncludesPrerelease())
+	if err != nil {
+		return nil, err
+	}
+
+	best := LatestMatching(releases, selector)
+	if best == nil {
+		return nil, fmt.Errorf("%w: no release matches the selector on %s", ErrNotFound, platform)
+	}
+
+	return best, nil
+}
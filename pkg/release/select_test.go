@@ -0,0 +1,45 @@
+package release
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/wellplayedgames/unity-installer/pkg/editor"
+)
+
+var _ = Describe("SelectRelease", func() {
+	source := &testSource{releases: Releases{
+		"2019.4.9f1":  {Version: "2019.4.9f1", LTS: true},
+		"2019.4.10f1": {Version: "2019.4.10f1", LTS: true},
+		"2020.1.0f1":  {Version: "2020.1.0f1"},
+		"2020.2.0b1":  {Version: "2020.2.0b1"},
+	}}
+
+	selectVersion := func(expr string) (*EditorRelease, error) {
+		selector, err := editor.ParseVersionSelector(expr)
+		Expect(err).NotTo(HaveOccurred())
+		return SelectRelease(context.Background(), source, "linux", selector, "")
+	}
+
+	It("should pick the highest matching version", func() {
+		for expr, expected := range map[string]string{
+			"2019.4.x":     "2019.4.10f1",
+			"latest-lts":   "2019.4.10f1",
+			"latest":       "2020.1.0f1",
+			"latest-beta":  "2020.2.0b1",
+			"<2019.4.10f1": "2019.4.9f1",
+			"2019.4.9f1":   "2019.4.9f1",
+		} {
+			release, err := selectVersion(expr)
+			Expect(err).NotTo(HaveOccurred(), expr)
+			Expect(release.Version).To(Equal(expected), expr)
+		}
+	})
+
+	It("should fail when nothing matches", func() {
+		_, err := selectVersion("latest:2021")
+		Expect(errors.Is(err, ErrNotFound)).To(BeTrue())
+	})
+})
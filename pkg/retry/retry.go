@@ -0,0 +1,180 @@
+// Package retry provides a retry policy for transient HTTP failures, shared
+// by release lookups and package downloads.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+const (
+	maxErrorBody = 1024
+)
+
+// DefaultPolicy is the retry policy used when none is configured.
+var DefaultPolicy = Policy{
+	Attempts:  4,
+	BaseDelay: time.Second,
+	MaxDelay:  30 * time.Second,
+}
+
+// Policy describes how many times and how quickly to retry an operation.
+//
+// Delays grow exponentially from BaseDelay up to MaxDelay with random jitter,
+// unless the server specifies a delay with Retry-After.
+type Policy struct {
+	Attempts  int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	// Logger is optional and used to report retries.
+	Logger logr.Logger
+}
+
+// StatusError is returned when a server responds with an unexpected HTTP
+// status.
+type StatusError struct {
+	URL        string
+	StatusCode int
+	RetryAfter time.Duration
+	Body       string
+}
+
+// NewStatusError creates a StatusError from a response, consuming (part of)
+// the response body.
+func NewStatusError(resp *http.Response) *StatusError {
+	bodyBytes, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	return &StatusError{
+		URL:        resp.Request.URL.String(),
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		Body:       string(bodyBytes),
+	}
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("bad status %d fetching %s", e.StatusCode, e.URL)
+	}
+
+	return fmt.Sprintf("bad status %d fetching %s: %s", e.StatusCode, e.URL, e.Body)
+}
+
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
+
+// IsRetryable returns true if err is likely to be transient: a 5xx or 429
+// response, a dropped or refused connection, or a network error which is a
+// timeout or temporary. Errors such as unknown hosts or invalid certificates
+// are not retried.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500 || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+
+	if errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return netErr.Timeout() || netErr.Temporary()
+	}
+
+	return false
+}
+
+// IsUnreachable returns true if err means that a server couldn't be reached
+// or failed to respond, even if retrying would not help, so that another
+// source for the same data can be used instead.
+func IsUnreachable(err error) bool {
+	if IsRetryable(err) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// Do calls fn until it succeeds, returns an error which is not retryable, or
+// the policy runs out of attempts.
+func (p Policy) Do(ctx context.Context, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.Attempts || !IsRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		delay := p.delay(attempt, err)
+		if p.Logger != nil {
+			p.Logger.Info("retrying after error", "error", err.Error(), "attempt", attempt, "delay", delay.String())
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (p Policy) delay(attempt int, err error) time.Duration {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+		if p.MaxDelay > 0 && statusErr.RetryAfter > p.MaxDelay {
+			return p.MaxDelay
+		}
+		return statusErr.RetryAfter
+	}
+
+	backoff := p.BaseDelay
+	for n := 1; n < attempt && (p.MaxDelay <= 0 || backoff < p.MaxDelay); n++ {
+		backoff *= 2
+	}
+
+	if p.MaxDelay > 0 && backoff > p.MaxDelay {
+		backoff = p.MaxDelay
+	}
+
+	if backoff <= 0 {
+		return 0
+	}
+
+	// Jitter between half and the full backoff.
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(backoff-half)+1))
+}
@@ -0,0 +1,100 @@
+package retry
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"syscall"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Policy", func() {
+	policy := Policy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+
+	fetch := func(url string) error {
+		resp, err := http.Get(url)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return NewStatusError(resp)
+		}
+		return nil
+	}
+
+	It("should retry server errors until success", func() {
+		calls := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls < 3 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		defer server.Close()
+
+		Expect(policy.Do(context.Background(), func() error { return fetch(server.URL) })).To(Succeed())
+		Expect(calls).To(Equal(3))
+	})
+
+	It("should not retry client errors", func() {
+		calls := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		err := policy.Do(context.Background(), func() error { return fetch(server.URL) })
+		var statusErr *StatusError
+		Expect(errors.As(err, &statusErr)).To(BeTrue())
+		Expect(statusErr.StatusCode).To(Equal(http.StatusNotFound))
+		Expect(calls).To(Equal(1))
+	})
+
+	It("should give up after the configured attempts", func() {
+		calls := 0
+		err := policy.Do(context.Background(), func() error {
+			calls++
+			return &StatusError{StatusCode: http.StatusTooManyRequests}
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(calls).To(Equal(3))
+	})
+
+	It("should honour Retry-After", func() {
+		Expect(parseRetryAfter("5")).To(Equal(5 * time.Second))
+		Expect(policy.delay(1, &StatusError{StatusCode: 503, RetryAfter: 5 * time.Millisecond})).To(Equal(5 * time.Millisecond))
+		Expect(policy.delay(1, &StatusError{StatusCode: 503, RetryAfter: time.Minute})).To(Equal(policy.MaxDelay))
+	})
+	It("should only retry transient network errors", func() {
+		urlErr := func(err error) error {
+			return &url.Error{Op: "Get", URL: "https://example.com/", Err: err}
+		}
+		dial := func(err error) error {
+			return urlErr(&net.OpError{Op: "dial", Net: "tcp", Err: err})
+		}
+
+		Expect(IsRetryable(dial(os.NewSyscallError("connect", syscall.ECONNREFUSED)))).To(BeTrue())
+		Expect(IsRetryable(urlErr(io.ErrUnexpectedEOF))).To(BeTrue())
+		Expect(IsRetryable(urlErr(&net.DNSError{Err: "i/o timeout", Name: "example.com", IsTimeout: true}))).To(BeTrue())
+
+		Expect(IsRetryable(dial(&net.DNSError{Err: "no such host", Name: "example.com", IsNotFound: true}))).To(BeFalse())
+		Expect(IsRetryable(urlErr(x509.UnknownAuthorityError{}))).To(BeFalse())
+
+		// They still mean that the server can't be reached.
+		Expect(IsUnreachable(dial(&net.DNSError{Err: "no such host", Name: "example.com", IsNotFound: true}))).To(BeTrue())
+		Expect(IsUnreachable(urlErr(context.Canceled))).To(BeFalse())
+	})
+})